@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// accountCommand creates the account command and its subcommands
+func (c *commands) accountCommand() *cobra.Command {
+	accountCmd := &cobra.Command{
+		Use:   "account",
+		Short: "Manage the accounts expenses are paid with",
+		Long:  "Manage the payment methods and funding accounts, such as credit cards, debit cards, cash or shared accounts",
+		Args:  cobra.NoArgs,
+	}
+
+	addCmd := &cobra.Command{
+		Use:     "add",
+		Short:   "Add a new account",
+		Example: "expense-tracker account add --name \"Amex\" --type credit",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if parsed, err := expense.ParseAccountName(name); err != nil {
+				fmt.Println(err)
+				return
+			} else {
+				name = parsed
+			}
+
+			accountType, _ := cmd.Flags().GetString("type")
+			parsedType, err := expense.ParseAccountType(accountType)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			if err := c.accounts.Add(expense.Account{Name: name, Type: parsedType}); err != nil {
+				fmt.Println("Error adding account:", err)
+				return
+			}
+
+			fmt.Printf("Account %s added successfully\n", name)
+		},
+	}
+	addCmd.Flags().String("name", "", "Account name (required)")
+	addCmd.Flags().String("type", "", "Account type, one of credit, debit, cash, shared (required)")
+	addCmd.MarkFlagRequired("name")
+	addCmd.MarkFlagRequired("type")
+	accountCmd.AddCommand(addCmd)
+
+	accountCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List all accounts",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			accounts, err := c.accounts.List()
+			if err != nil {
+				fmt.Println("Error listing accounts:", err)
+				return
+			}
+
+			if len(accounts) == 0 {
+				fmt.Println("No accounts to display.")
+				return
+			}
+
+			rows := make([][]string, len(accounts))
+			for i, account := range accounts {
+				rows[i] = []string{account.Name, string(account.Type)}
+			}
+			c.printTable(table{headers: []string{"Name", "Type"}, rows: rows})
+		},
+	})
+
+	deleteCmd := &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete an account, expenses paid with it keep its name",
+		Example: "expense-tracker account delete --name \"Amex\"",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if err := c.accounts.Delete(name); err != nil {
+				fmt.Println("Error deleting account:", err)
+				return
+			}
+
+			fmt.Printf("Account %s deleted successfully\n", name)
+		},
+	}
+	deleteCmd.Flags().String("name", "", "Account name (required)")
+	deleteCmd.MarkFlagRequired("name")
+	accountCmd.AddCommand(deleteCmd)
+
+	return accountCmd
+}
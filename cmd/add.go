@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/toramanomer/expense-tracker/expense"
@@ -10,17 +15,21 @@ import (
 // addCommand creates the add command
 func (c *commands) addCommand() *cobra.Command {
 	addCmd := &cobra.Command{
-		Use:     "add",
-		Short:   "Add a new expense",
-		Long:    "Add a new expense with description, amount (in dollars), and category",
-		Example: "expense-tracker add --category \"Food\" --description \"Lunch\" --amount 20",
-		Args:    cobra.NoArgs,
+		Use:   "add",
+		Short: "Add a new expense",
+		Long:  "Add a new expense with description, amount (in dollars), and category.\nWithout a category, the first matching rule of the rules file categorizes the expense.\nIf no rule matches, categories learned from similar expenses are suggested to choose from.",
+		Example: "expense-tracker add --category \"Food\" --description \"Lunch\" --amount 20 --note \"Team lunch\" --meta project=apollo\n" +
+			"expense-tracker add --category \"Groceries\" --description \"Weekly shop\" --amount 60 --paid-by Alice --participant Alice --participant Bob --participant Carol",
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
+			// Without a category the expense is categorized by the rules
 			category, _ := cmd.Flags().GetString("category")
-			if parsed, err := expense.ParseCategory(category); err != nil {
-				fmt.Println(err)
-				return
-			} else {
+			if cmd.Flags().Changed("category") {
+				parsed, err := expense.ParseCategory(category)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
 				category = parsed
 			}
 
@@ -32,29 +41,244 @@ func (c *commands) addCommand() *cobra.Command {
 				description = parsed
 			}
 
+			var opts []expense.ExpenseOption
+
+			kind, _ := cmd.Flags().GetString("kind")
+			if parsed, err := expense.ParseKind(kind); err != nil {
+				fmt.Println(err)
+				return
+			} else {
+				opts = append(opts, expense.WithKind(parsed))
+			}
+
+			var splits []expense.Split
+			rawSplits, _ := cmd.Flags().GetStringArray("split")
+			for _, raw := range rawSplits {
+				split, err := expense.ParseSplit(raw)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				splits = append(splits, split)
+			}
+
+			// The amount of a split expense defaults to the sum of its splits
 			amount, _ := cmd.Flags().GetInt("amount")
+			if !cmd.Flags().Changed("amount") {
+				if len(splits) == 0 {
+					fmt.Println("Please provide the amount with --amount or split the expense with --split")
+					return
+				}
+				amount = expense.SplitsAmount(splits)
+			}
 			if err := expense.ValidateAmount(amount); err != nil {
 				fmt.Println(err)
 				return
 			}
 
-			expense, err := c.service.AddExpense(category, description, amount)
+			if err := expense.ValidateSplits(splits, amount); err != nil {
+				fmt.Println(err)
+				return
+			} else if len(splits) > 0 {
+				opts = append(opts, expense.WithSplits(splits))
+			}
+
+			notes, _ := cmd.Flags().GetString("note")
+			if parsed, err := expense.ParseNotes(notes); err != nil {
+				fmt.Println(err)
+				return
+			} else if parsed != "" {
+				opts = append(opts, expense.WithNotes(parsed))
+			}
+
+			pairs, _ := cmd.Flags().GetStringArray("meta")
+			if metadata, err := expense.ParseMetadata(pairs); err != nil {
+				fmt.Println(err)
+				return
+			} else if metadata != nil {
+				opts = append(opts, expense.WithMetadata(metadata))
+			}
+
+			if name, _ := cmd.Flags().GetString("account"); name != "" {
+				account, err := c.accounts.Find(name)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				opts = append(opts, expense.WithAccount(account.Name))
+			}
+
+			payee, _ := cmd.Flags().GetString("payee")
+			if payee != "" {
+				parsed, err := expense.ParsePayee(payee)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+
+				if payee, err = c.payees.Normalize(parsed); err != nil {
+					fmt.Println("Error normalizing payee:", err)
+					return
+				}
+				opts = append(opts, expense.WithPayee(payee))
+			}
+
+			tags, _ := cmd.Flags().GetStringArray("tag")
+			if parsed, err := expense.ParseTags(tags); err != nil {
+				fmt.Println(err)
+				return
+			} else if len(parsed) > 0 {
+				opts = append(opts, expense.WithTags(parsed))
+			}
+
+			if shareOpt, err := c.sharesFromFlags(cmd, amount); err != nil {
+				fmt.Println(err)
+				return
+			} else if shareOpt != nil {
+				opts = append(opts, shareOpt)
+			}
+
+			added, err := c.service.AddExpense(cmd.Context(), category, description, amount, opts...)
+			if errors.Is(err, expense.ErrNoCategory) {
+				// No rule matched, let the user pick one of the categories learned from the history
+				if category, err = c.chooseCategory(cmd.Context(), description, payee); err != nil {
+					fmt.Println("Error adding expense:", err)
+					return
+				}
+				if category == "" {
+					fmt.Println("Error adding expense: no rule matched, please provide a category with --category")
+					return
+				}
+				added, err = c.service.AddExpense(cmd.Context(), category, description, amount, opts...)
+			}
 			if err != nil {
 				fmt.Println("Error adding expense:", err)
 				return
 			}
 
-			fmt.Printf("Expense added successfully (ID: %d)\n", expense.ID)
+			fmt.Printf("Expense added successfully (ID: %d)\n", added.ID)
+			if !cmd.Flags().Changed("category") && category == "" {
+				fmt.Printf("Categorized as %s\n", added.Category)
+			}
 		},
 	}
 
-	addCmd.Flags().StringP("category", "c", "", "Expense category (required)")
+	addCmd.Flags().StringP("category", "c", "", "Expense category, assigned by the rules if omitted")
 	addCmd.Flags().StringP("description", "d", "", "Expense description (required)")
-	addCmd.Flags().IntP("amount", "a", 0, "Expense amount (required)")
+	addCmd.Flags().IntP("amount", "a", 0, "Expense amount (required unless split)")
+	addCmd.Flags().StringP("note", "n", "", "Expense notes, may span multiple lines")
+	addCmd.Flags().String("kind", string(expense.KindExpense), "Kind of the transaction, one of expense, income, transfer")
+	addCmd.Flags().StringArray("meta", nil, "Expense metadata as key=value, can be repeated")
+	addCmd.Flags().String("account", "", "Account the expense was paid with, see the account command")
+	addCmd.Flags().StringArray("split", nil, "Part of the expense in its own category as category=amount[:note], can be repeated")
+	addCmd.Flags().StringArray("tag", nil, "Expense tag, can be repeated")
+	addCmd.Flags().String("payee", "", "Merchant or person the expense was paid to, normalized by the payee command")
+	addCmd.Flags().String("paid-by", "", "Person who paid a shared expense, see the people command")
+	addCmd.Flags().StringArray("participant", nil, "Person sharing the expense as name or name=value, can be repeated")
+	addCmd.Flags().String("share-mode", string(expense.ShareEqual), "How the expense is shared, one of equal, percent, exact")
 
-	addCmd.MarkFlagRequired("category")
 	addCmd.MarkFlagRequired("description")
-	addCmd.MarkFlagRequired("amount")
 
 	return addCmd
 }
+
+// sharesFromFlags shares the expense between the participants given by the flags, nil if it's not shared.
+// The payer and the participants must be registered people.
+func (c *commands) sharesFromFlags(cmd *cobra.Command, amount int) (expense.ExpenseOption, error) {
+	paidBy, _ := cmd.Flags().GetString("paid-by")
+	rawParticipants, _ := cmd.Flags().GetStringArray("participant")
+	if paidBy == "" && len(rawParticipants) == 0 {
+		return nil, nil
+	}
+	if paidBy == "" || len(rawParticipants) == 0 {
+		return nil, errors.New("please provide both --paid-by and --participant to share an expense")
+	}
+
+	mode, _ := cmd.Flags().GetString("share-mode")
+	shareMode, err := expense.ParseShareMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if paidBy, err = c.people.Find(paidBy); err != nil {
+		return nil, err
+	}
+
+	participants := make([]expense.Participant, len(rawParticipants))
+	for i, raw := range rawParticipants {
+		participant, err := expense.ParseParticipant(raw)
+		if err != nil {
+			return nil, err
+		}
+		if participant.Person, err = c.people.Find(participant.Person); err != nil {
+			return nil, err
+		}
+		participants[i] = participant
+	}
+
+	shares, err := expense.ComputeShares(amount, shareMode, participants)
+	if err != nil {
+		return nil, err
+	}
+	return expense.WithShares(paidBy, shares), nil
+}
+
+// chooseCategory suggests the most likely categories for the expense and lets the user choose
+// one of them or type another one. An empty category is returned if the user chose none
+// or cannot be asked since the standard input is not a terminal.
+func (c *commands) chooseCategory(ctx context.Context, description, payee string) (string, error) {
+	suggestions, err := c.service.SuggestCategories(ctx, description, payee, 3)
+	if err != nil {
+		return "", err
+	}
+
+	if !isTerminal(os.Stdin) {
+		if len(suggestions) > 0 {
+			fmt.Printf("Suggested categories: %s\n", formatSuggestions(suggestions))
+		}
+		return "", nil
+	}
+
+	// Without suggestions there is nothing to choose by number, any answer is a category
+	question := "Type a category (empty to cancel): "
+	if len(suggestions) == 0 {
+		fmt.Println("No rule matched and no similar expenses were found.")
+	} else {
+		fmt.Println("No rule matched, suggested categories based on similar expenses:")
+		for i, suggestion := range suggestions {
+			fmt.Printf("  %d) %s (%.0f%%)\n", i+1, suggestion.Category, suggestion.Confidence*100)
+		}
+		question = "Choose a category by number or type a new one (empty to cancel): "
+	}
+
+	for {
+		answer, err := prompt(question)
+		if err != nil || answer == "" {
+			return "", err
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && len(suggestions) > 0 {
+			if n >= 1 && n <= len(suggestions) {
+				return suggestions[n-1].Category, nil
+			}
+			fmt.Printf("Please enter a number between 1 and %d.\n", len(suggestions))
+			continue
+		}
+
+		category, err := expense.ParseCategory(answer)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		return category, nil
+	}
+}
+
+// formatSuggestions formats the suggestions with their confidence on a single line
+func formatSuggestions(suggestions []expense.Suggestion) string {
+	formatted := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		formatted[i] = fmt.Sprintf("%s (%.0f%%)", suggestion.Category, suggestion.Confidence*100)
+	}
+	return strings.Join(formatted, ", ")
+}
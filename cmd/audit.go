@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// formatIDs formats the IDs of the expenses affected by the audit entry
+func formatIDs(entry expense.AuditEntry) string {
+	seen := make(map[int]bool)
+	var ids []string
+	for _, e := range append(append([]expense.Expense{}, entry.Before...), entry.After...) {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			ids = append(ids, strconv.Itoa(e.ID))
+		}
+	}
+	return strings.Join(ids, " ")
+}
+
+// auditCommand creates the audit command and its subcommands
+func (c *commands) auditCommand() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "List and verify the log of all changes",
+		Long: "List who changed the expenses, when and with which command, filtered by the flags.\n" +
+			"Each entry is chained to the previous one by its hash and the last one is also kept in audit-head.txt,\n" +
+			"audit verify detects modified, removed or truncated entries unless both files are rewritten consistently.",
+		Example: "expense-tracker audit --id 3\n" +
+			"expense-tracker audit --user alice --since 2025-03-01 --op delete -o json",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			since, _ := cmd.Flags().GetString("since")
+			sinceDay, err := parseDay(since)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			// Changes are recorded in local time, so the day starts at local midnight
+			if !sinceDay.IsZero() {
+				sinceDay = time.Date(sinceDay.Year(), sinceDay.Month(), sinceDay.Day(), 0, 0, 0, 0, time.Local)
+			}
+			id, _ := cmd.Flags().GetInt("id")
+			op, _ := cmd.Flags().GetString("op")
+			username, _ := cmd.Flags().GetString("user")
+
+			entries, err := c.auditLog.Entries()
+			if err != nil {
+				fmt.Println("Error reading audit log:", err)
+				return
+			}
+
+			var selected []expense.AuditEntry
+			for _, entry := range entries {
+				switch {
+				case id != 0 && !entry.Affects(id):
+				case op != "" && !strings.EqualFold(op, string(entry.Op)):
+				case username != "" && username != entry.User:
+				case !sinceDay.IsZero() && entry.Time.Before(sinceDay):
+				default:
+					selected = append(selected, entry)
+				}
+			}
+
+			switch output {
+			case "json":
+				err = printJSON(selected)
+			case "csv":
+				rows := make([][]string, len(selected))
+				for i, entry := range selected {
+					rows[i] = []string{
+						strconv.Itoa(entry.Seq),
+						entry.Time.Format(time.RFC3339),
+						entry.User,
+						entry.Host,
+						string(entry.Op),
+						formatIDs(entry),
+						entry.Command,
+						entry.Hash,
+						entry.Note,
+					}
+				}
+				err = printCSV([]string{"seq", "time", "user", "host", "op", "expenses", "command", "hash", "note"}, rows)
+			default:
+				if len(selected) == 0 {
+					fmt.Println("No changes to display.")
+					return
+				}
+
+				rows := make([][]string, len(selected))
+				for i, entry := range selected {
+					// Changes not affecting single expenses, such as a repair, show their details instead
+					expenses := formatIDs(entry)
+					if expenses == "" {
+						expenses = entry.Note
+					}
+					rows[i] = []string{
+						strconv.Itoa(entry.Seq),
+						entry.Time.Local().Format(time.DateTime),
+						entry.User + "@" + entry.Host,
+						string(entry.Op),
+						expenses,
+						entry.Command,
+					}
+				}
+				c.printTable(table{headers: []string{"Seq", "Time", "User", "Change", "Expenses", "Command"}, rows: rows})
+			}
+			if err != nil {
+				fmt.Println("Error printing audit log:", err)
+			}
+		},
+	}
+
+	auditCmd.Flags().Int("id", 0, "Only changes of the expense")
+	auditCmd.Flags().String("op", "", "Only changes of the kind, e.g. add, delete, edit, undo, empty_trash, repair, migrate")
+	auditCmd.Flags().String("user", "", "Only changes made by the operating system user")
+	auditCmd.Flags().String("since", "", "Only changes made on or after the day, as YYYY-MM-DD")
+	addOutputFlag(auditCmd)
+
+	auditCmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Verify that no entry of the audit log was modified or removed",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := c.auditLog.Verify()
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+
+			fmt.Printf("Audit log verified, %d entries intact\n", n)
+		},
+	})
+
+	return auditCmd
+}
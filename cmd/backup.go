@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/config"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// backupCommand creates the backup command
+func (c *commands) backupCommand() *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:     "backup",
+		Short:   "Back up all data to a JSON archive",
+		Long:    "Write a versioned JSON archive with all expenses, the ID counter, the trash, the undo history, the accounts,\npayees, people, settlements, categorization rules and settings, protected by a checksum",
+		Example: "expense-tracker backup --output expenses-backup.json",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			archive, err := c.service.Backup(cmd.Context())
+			if err != nil {
+				fmt.Println("Error creating backup:", err)
+				return
+			}
+
+			settings, err := config.ReadFile(c.settingsPath())
+			if err != nil {
+				fmt.Println("Error reading configuration:", err)
+				return
+			}
+			archive.Settings = settings.Values()
+
+			if err := c.backupRegistries(archive); err != nil {
+				fmt.Println("Error creating backup:", err)
+				return
+			}
+
+			output, _ := cmd.Flags().GetString("output")
+			if output == "" {
+				if err := expense.WriteArchive(os.Stdout, archive); err != nil {
+					fmt.Println("Error writing backup:", err)
+				}
+				return
+			}
+
+			file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				fmt.Println("Error creating backup file:", err)
+				return
+			}
+			defer file.Close()
+
+			if err := expense.WriteArchive(file, archive); err != nil {
+				fmt.Println("Error writing backup:", err)
+				return
+			}
+
+			fmt.Printf("Backed up %d expenses to %s\n", len(archive.Expenses), output)
+		},
+	}
+
+	backupCmd.Flags().StringP("output", "o", "", "File to write the backup to, must not exist (default stdout)")
+
+	return backupCmd
+}
+
+// restoreCommand creates the restore command
+func (c *commands) restoreCommand() *cobra.Command {
+	restoreCmd := &cobra.Command{
+		Use:     "restore",
+		Short:   "Restore expenses from a backup archive or the trash",
+		Long:    "Validate a backup archive and replace the current data with it, or merge its expenses into the current ones with --merge,\nwhich keeps the current trash, undo history, registries, rules and settings.\nWith --id, restore a deleted expense from the trash instead.",
+		Example: "expense-tracker restore --input expenses-backup.json --merge\nexpense-tracker restore --id 3",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("id") {
+				id, _ := cmd.Flags().GetInt("id")
+				c.restoreDeleted(cmd.Context(), id)
+				return
+			}
+
+			input, _ := cmd.Flags().GetString("input")
+			file, err := os.Open(input)
+			if err != nil {
+				fmt.Println("Error opening backup file:", err)
+				return
+			}
+			defer file.Close()
+
+			archive, err := expense.ReadArchive(file)
+			if err != nil {
+				fmt.Println("Error reading backup:", err)
+				return
+			}
+
+			mode := expense.RestoreReplace
+			if merge, _ := cmd.Flags().GetBool("merge"); merge {
+				mode = expense.RestoreMerge
+			}
+
+			if err := c.service.Restore(cmd.Context(), archive, mode); err != nil {
+				fmt.Println("Error restoring backup:", err)
+				return
+			}
+
+			// Registries and settings are only restored when replacing, merging keeps the current ones
+			if mode == expense.RestoreReplace && archive.Version >= 3 {
+				if err := c.restoreRegistries(archive); err != nil {
+					fmt.Println("Error restoring backup:", err)
+					return
+				}
+			}
+			if mode == expense.RestoreReplace && len(archive.Settings) > 0 {
+				if err := c.restoreSettings(archive.Settings); err != nil {
+					fmt.Println("Error restoring settings:", err)
+					return
+				}
+			}
+
+			fmt.Printf("Restored %d expenses from %s\n", len(archive.Expenses), input)
+		},
+	}
+
+	restoreCmd.Flags().StringP("input", "i", "", "Backup file to restore")
+	restoreCmd.Flags().Bool("merge", false, "Merge the backup into the current expenses instead of replacing them")
+	restoreCmd.Flags().Int("id", 0, "ID of a deleted expense to restore from the trash")
+	restoreCmd.MarkFlagsOneRequired("input", "id")
+	restoreCmd.MarkFlagsMutuallyExclusive("input", "id")
+
+	return restoreCmd
+}
+
+// backupRegistries adds the accounts, payees, people, settlements and rules to the archive
+func (c *commands) backupRegistries(archive *expense.Archive) error {
+	var err error
+	if archive.Accounts, err = c.accounts.List(); err != nil {
+		return fmt.Errorf("reading accounts: %w", err)
+	}
+	if archive.Payees, err = c.payees.List(); err != nil {
+		return fmt.Errorf("reading payees: %w", err)
+	}
+	if archive.People, err = c.people.List(); err != nil {
+		return fmt.Errorf("reading people: %w", err)
+	}
+	if archive.Settlements, err = c.settlements.List(); err != nil {
+		return fmt.Errorf("reading settlements: %w", err)
+	}
+
+	rules, err := c.rules.Raw()
+	if err != nil {
+		return fmt.Errorf("reading rules: %w", err)
+	}
+	archive.Rules = string(rules)
+	return nil
+}
+
+// restoreRegistries replaces the accounts, payees, people, settlements and rules with the ones of the archive
+func (c *commands) restoreRegistries(archive *expense.Archive) error {
+	if err := c.accounts.Replace(archive.Accounts); err != nil {
+		return fmt.Errorf("restoring accounts: %w", err)
+	}
+	if err := c.payees.Replace(archive.Payees); err != nil {
+		return fmt.Errorf("restoring payees: %w", err)
+	}
+	if err := c.people.Replace(archive.People); err != nil {
+		return fmt.Errorf("restoring people: %w", err)
+	}
+	if err := c.settlements.Replace(archive.Settlements); err != nil {
+		return fmt.Errorf("restoring settlements: %w", err)
+	}
+	if err := c.rules.WriteRaw([]byte(archive.Rules)); err != nil {
+		return fmt.Errorf("restoring rules: %w", err)
+	}
+	return nil
+}
+
+// restoreSettings sets the archived settings in the current configuration file.
+// Settings missing from the archive are kept, and the local ones such as data_dir are never restored,
+// as they may not apply to this installation.
+func (c *commands) restoreSettings(archived map[string]string) error {
+	path := c.settingsPath()
+	settings, err := config.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(archived)) {
+		if slices.Contains(config.LocalKeys, key) {
+			continue
+		}
+		if err := settings.Set(key, archived[key]); err != nil {
+			fmt.Println("Skipping setting from backup:", err)
+		}
+	}
+
+	return config.WriteFile(path, settings)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toramanomer/expense-tracker/config"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	src, dst := t.TempDir(), t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		require.NoError(t, executeWith(t, path, append([]string{"--data-dir", dir}, args...)...))
+	}
+
+	run(src, "add", "--category", "Food", "--description", "Lunch", "--amount", "10", "--tag", "work")
+	run(src, "add", "--category", "Food", "--description", "Dinner", "--amount", "20")
+	run(src, "delete", "--id", "2", "--yes")
+	run(src, "account", "add", "--name", "Amex", "--type", "credit")
+	run(src, "payee", "add", "--name", "Amazon", "--match", "^AMZN")
+	run(src, "people", "add", "--name", "Alice")
+	run(src, "people", "add", "--name", "Bob")
+	run(src, "settle", "--from", "Alice", "--to", "Bob", "--amount", "5")
+	rules := "rules:\n  - name: coffee\n    match:\n      description: latte\n    set:\n      category: Coffee\n"
+	require.NoError(t, os.WriteFile(filepath.Join(src, "rules.yaml"), []byte(rules), 0644))
+
+	archive := filepath.Join(t.TempDir(), "backup.json")
+	run(src, "backup", "--output", archive)
+	run(dst, "restore", "--input", archive)
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		name := entry.Name()
+		want, err := os.ReadFile(filepath.Join(src, name))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		require.NoError(t, err, "%s is restored", name)
+
+		switch name {
+		case "audit.jsonl", "audit-head.txt":
+			// The audit log is not restored, it records the restore instead
+			log := expense.NewAuditLogFS(dst, expense.Actor{})
+			_, err := log.Verify()
+			require.NoError(t, err)
+			entries, err := log.Entries()
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, expense.OpReplace, entries[0].Op)
+		case "journal.jsonl":
+			// The restore is recorded after the restored history, so it can be undone
+			assert.True(t, strings.HasPrefix(string(got), string(want)), "%s keeps the restored history", name)
+		case "journal-seq.txt":
+			seq, err := strconv.Atoi(string(want))
+			require.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(seq+1), string(got), "the restore is numbered after the restored history")
+		default:
+			assert.Equal(t, string(want), string(got), "%s is restored", name)
+		}
+	}
+}
+
+func TestRestore_Settings(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	srcConfig := filepath.Join(t.TempDir(), "config.yaml")
+	dstConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, config.WriteFile(srcConfig, &config.Config{DataDir: src, Storage: "events", Currency: "EUR"}))
+	require.NoError(t, config.WriteFile(dstConfig, &config.Config{DataDir: dst, WeekStart: "sunday"}))
+
+	archive := filepath.Join(t.TempDir(), "backup.json")
+	require.NoError(t, executeWith(t, srcConfig, "backup", "--output", archive))
+	require.NoError(t, executeWith(t, dstConfig, "restore", "--input", archive))
+
+	restored, err := config.ReadFile(dstConfig)
+	require.NoError(t, err)
+	assert.Equal(t, &config.Config{DataDir: dst, Currency: "EUR", WeekStart: "sunday"}, restored,
+		"the archived preferences are merged and the local settings are kept")
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/config"
+)
+
+// configCommand creates the config command and its subcommands
+func (c *commands) configCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get and set configuration values",
+		Long:  "Get and set configuration values stored in the configuration file.\nValues can be overridden by " + config.EnvPrefix + "<KEY> environment variables and flags.",
+		Args:  cobra.NoArgs,
+		// The configuration commands do not need the storage.
+		// An invalid configuration file is reported by get and list, set can still fix it.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if c.configErr = c.loadConfig(cmd); c.configErr != nil {
+				c.config = config.Default()
+				c.config.Profile = c.fallbackProfile(cmd)
+			}
+			return nil
+		},
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:     "get <key>",
+		Short:   "Print the effective value of a key",
+		Example: "expense-tracker config get currency",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if c.configErr != nil {
+				fmt.Println(c.configErr)
+				return
+			}
+
+			value, err := c.config.Get(args[0])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Println(value)
+		},
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:     "set <key> <value>",
+		Short:   "Set a key in the configuration file of the active profile, an empty value unsets it",
+		Example: "expense-tracker config set currency EUR",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if args[0] == "profile" {
+				fmt.Println("Use profile switch to change the active profile")
+				return
+			}
+
+			path := c.settingsPath()
+			// Other invalid values are kept, so they can be fixed one at a time
+			file, err := config.ParseFile(path)
+			if err != nil {
+				fmt.Println("Error reading configuration:", err)
+				return
+			}
+
+			if err := file.Set(args[0], args[1]); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			if err := config.WriteFile(path, file); err != nil {
+				fmt.Println("Error writing configuration:", err)
+				return
+			}
+
+			fmt.Printf("Set %s in %s\n", args[0], path)
+		},
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the effective value of all keys",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if c.configErr != nil {
+				fmt.Println(c.configErr)
+				return
+			}
+
+			fmt.Println("# " + c.settingsPath())
+			for _, key := range config.Keys() {
+				value, _ := c.config.Get(key)
+				fmt.Printf("%s = %s\n", key, value)
+			}
+		},
+	})
+
+	return configCmd
+}
+
+// fallbackProfile returns the profile selected by the --profile flag or the environment, or the active one
+// read from the global configuration file regardless of its other values, for when the configuration cannot be loaded
+func (c *commands) fallbackProfile(cmd *cobra.Command) string {
+	flag, _ := cmd.Flags().GetString("profile")
+	candidates := []string{flag, os.Getenv(config.EnvPrefix + "PROFILE")}
+	if file, err := config.ParseFile(c.configPath); err == nil {
+		candidates = append(candidates, file.Profile)
+	}
+
+	for _, profile := range candidates {
+		if profile != "" && config.ValidateProfileName(profile) == nil && config.ProfileExists(c.configPath, profile) {
+			return profile
+		}
+	}
+	return config.DefaultProfile
+}
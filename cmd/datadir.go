@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/toramanomer/expense-tracker/config"
+)
+
+const (
+	// legacyDataDir is the directory, relative to the working directory, used by earlier versions
+	legacyDataDir = "data"
+	// migrationDeclinedFile marks a data directory whose owner declined migrating the legacy data
+	migrationDeclinedFile = ".legacy-migration-declined"
+)
+
+// rename renames a file, replaced in tests to exercise the copy fallback
+var rename = os.Rename
+
+// defaultDataDir returns the data directory of the profile following the XDG base directory specification,
+// $XDG_DATA_HOME/expense-tracker falling back to ~/.local/share/expense-tracker.
+// Profiles other than the default one are kept in the profiles subdirectory.
+func defaultDataDir(profile string) (string, error) {
+	var dir string
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		dir = filepath.Join(xdg, "expense-tracker")
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share", "expense-tracker")
+	}
+
+	if profile != config.DefaultProfile {
+		dir = filepath.Join(dir, "profiles", profile)
+	}
+	return dir, nil
+}
+
+// resolveDataDir returns the data directory of the profile, preferring the flag over the configured
+// directory over the default. explicit reports whether the directory was chosen by the user.
+func resolveDataDir(flag, configured, profile string) (dir string, explicit bool, err error) {
+	if flag != "" {
+		return flag, true, nil
+	}
+
+	if configured != "" {
+		return configured, true, nil
+	}
+
+	dir, err = defaultDataDir(profile)
+	return dir, false, err
+}
+
+// hasExpenseData reports whether dir contains expense data files
+func hasExpenseData(dir string) bool {
+	for _, name := range []string{"expenses.txt", "ids.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// offerLegacyMigration asks once to move the data of earlier versions from ./data into dir.
+// The offer is only made on a terminal when dir holds no data yet; a declined offer is remembered.
+func offerLegacyMigration(dir string, out io.Writer) error {
+	if !hasExpenseData(legacyDataDir) || hasExpenseData(dir) || !isTerminal(os.Stdin) {
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, migrationDeclinedFile)); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Found expense data in %s from an earlier version. Move it to %s? [y/N] ", legacyDataDir, dir)
+	answer, err := readLine()
+	if err != nil {
+		return err
+	}
+
+	if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+		fmt.Fprintf(out, "Keeping %s untouched, use --data-dir %s to keep using it.\n", legacyDataDir, legacyDataDir)
+		return os.WriteFile(filepath.Join(dir, migrationDeclinedFile), nil, 0644)
+	}
+
+	if err := moveDir(legacyDataDir, dir); err != nil {
+		return fmt.Errorf("migrating %s: %w", legacyDataDir, err)
+	}
+
+	fmt.Fprintf(out, "Moved expense data to %s\n", dir)
+	return nil
+}
+
+// moveDir moves the regular files of src into dst and removes src if it is left empty.
+// If any of the files already exists in dst, nothing is moved.
+func moveDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(entry os.DirEntry) bool { return !entry.Type().IsRegular() })
+
+	// Renaming replaces existing files, so conflicts are looked for before moving anything
+	for _, entry := range entries {
+		to := filepath.Join(dst, entry.Name())
+		if _, err := os.Lstat(to); err == nil {
+			return fmt.Errorf("%s already exists", to)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		from, to := filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())
+		// Renaming fails across file systems, fall back to copying
+		if err := rename(from, to); err != nil {
+			if err := copyFile(from, to); err != nil {
+				return err
+			}
+			if err := os.Remove(from); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Removing fails if src is not empty, which is fine
+	os.Remove(src)
+	return nil
+}
+
+// copyFile copies the content of src into a new file dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates the files with their content in dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+// assertFile asserts the content of the file
+func assertFile(t *testing.T, path, content string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestOfferLegacyMigration(t *testing.T) {
+	defer func(original func(*os.File) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(*os.File) bool { return true }
+
+	t.Run("move", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		writeFiles(t, legacyDataDir, map[string]string{"ids.txt": "1"})
+		dir := filepath.Join(t.TempDir(), "data")
+
+		typeAnswer(t, "y\nnext")
+		require.NoError(t, offerLegacyMigration(dir, io.Discard))
+		assertFile(t, filepath.Join(dir, "ids.txt"), "1")
+
+		answer, err := readLine()
+		require.NoError(t, err)
+		assert.Equal(t, "next", answer, "later answers are left to the next prompt")
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		writeFiles(t, legacyDataDir, map[string]string{"ids.txt": "1"})
+		dir := filepath.Join(t.TempDir(), "data")
+
+		typeAnswer(t, "n")
+		require.NoError(t, offerLegacyMigration(dir, io.Discard))
+		assert.FileExists(t, filepath.Join(legacyDataDir, "ids.txt"))
+		assert.FileExists(t, filepath.Join(dir, migrationDeclinedFile))
+	})
+}
+
+func TestMoveDir(t *testing.T) {
+	files := map[string]string{"expenses.txt": "1,2025-04-20,Food,Lunch,10\n", "ids.txt": "1"}
+
+	t.Run("move", func(t *testing.T) {
+		src, dst := filepath.Join(t.TempDir(), "data"), t.TempDir()
+		writeFiles(t, src, files)
+
+		require.NoError(t, moveDir(src, dst))
+		for name, content := range files {
+			assertFile(t, filepath.Join(dst, name), content)
+		}
+		assert.NoDirExists(t, src, "the emptied directory is removed")
+	})
+
+	t.Run("other entries are left behind", func(t *testing.T) {
+		src, dst := filepath.Join(t.TempDir(), "data"), t.TempDir()
+		writeFiles(t, src, files)
+		require.NoError(t, os.Mkdir(filepath.Join(src, "old"), os.ModePerm))
+
+		require.NoError(t, moveDir(src, dst))
+		assertFile(t, filepath.Join(dst, "ids.txt"), "1")
+		assert.DirExists(t, filepath.Join(src, "old"))
+		assert.NoFileExists(t, filepath.Join(src, "ids.txt"))
+	})
+
+	t.Run("copy fallback", func(t *testing.T) {
+		defer func(original func(string, string) error) { rename = original }(rename)
+		rename = func(string, string) error { return errors.New("invalid cross-device link") }
+
+		src, dst := filepath.Join(t.TempDir(), "data"), t.TempDir()
+		writeFiles(t, src, files)
+
+		require.NoError(t, moveDir(src, dst))
+		for name, content := range files {
+			assertFile(t, filepath.Join(dst, name), content)
+		}
+		assert.NoDirExists(t, src)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		src, dst := filepath.Join(t.TempDir(), "data"), t.TempDir()
+		writeFiles(t, src, files)
+		writeFiles(t, dst, map[string]string{"ids.txt": "7"})
+
+		assert.ErrorContains(t, moveDir(src, dst), "already exists")
+		assertFile(t, filepath.Join(dst, "ids.txt"), "7")
+		assert.NoFileExists(t, filepath.Join(dst, "expenses.txt"), "nothing is moved")
+		for name, content := range files {
+			assertFile(t, filepath.Join(src, name), content)
+		}
+	})
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	require.NoError(t, copyFile(filepath.Join(dir, "a.txt"), filepath.Join(dir, "c.txt")))
+	assertFile(t, filepath.Join(dir, "c.txt"), "a")
+
+	assert.Error(t, copyFile(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")), "existing files are not overwritten")
+	assertFile(t, filepath.Join(dir, "b.txt"), "b")
+}
+
+func TestResolveDataDir(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/xdg")
+
+	dir, explicit, err := resolveDataDir("/flag", "/configured", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "/flag", dir)
+	assert.True(t, explicit)
+
+	dir, explicit, err = resolveDataDir("", "/configured", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "/configured", dir)
+	assert.True(t, explicit)
+
+	dir, explicit, err = resolveDataDir("", "", "work")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/xdg", "expense-tracker", "profiles", "work"), dir)
+	assert.False(t, explicit)
+}
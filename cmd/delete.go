@@ -1,38 +1,106 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"github.com/toramanomer/expense-tracker/expense"
 )
 
+// selectWhere returns the expenses selected by the filter flags for a bulk change.
+// At least one filter is required, so a forgotten flag cannot select all expenses.
+func (c *commands) selectWhere(cmd *cobra.Command) ([]expense.Expense, error) {
+	filter, err := filterFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if filter.IsZero() {
+		return nil, errors.New("please provide at least one filter with --where, e.g. --category or --from")
+	}
+
+	expenses, err := c.service.ListExpenses(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(expenses), nil
+}
+
+// confirmBulk previews the expenses a bulk change affects and asks for confirmation unless --yes is given
+func (c *commands) confirmBulk(cmd *cobra.Command, expenses []expense.Expense, action string) (bool, error) {
+	c.printExpensesTable(expenses)
+
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	return confirm(fmt.Sprintf("%s %d expenses?", action, len(expenses)))
+}
+
 // deleteCommand creates the delete command
 func (c *commands) deleteCommand() *cobra.Command {
 	deleteCmd := &cobra.Command{
-		Use:     "delete",
-		Short:   "Delete an expense by ID",
-		Args:    cobra.NoArgs,
-		Example: "expense-tracker delete --id 2",
+		Use:   "delete",
+		Short: "Delete expenses by ID or filter, keeping them in the trash",
+		Long: "Delete an expense by its ID, or all expenses matching the filter flags with --where.\n" +
+			"Deleting by filter previews the expenses and asks for confirmation, skipped with --yes.",
+		Args: cobra.NoArgs,
+		Example: "expense-tracker delete --id 2\n" +
+			"expense-tracker delete --where --from 2025-03-01 --to 2025-03-31 --category Imported --yes",
 		Run: func(cmd *cobra.Command, args []string) {
+			if where, _ := cmd.Flags().GetBool("where"); where {
+				expenses, err := c.selectWhere(cmd)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				if len(expenses) == 0 {
+					fmt.Println("No expenses match the filter.")
+					return
+				}
+
+				if ok, err := c.confirmBulk(cmd, expenses, "Delete"); err != nil {
+					fmt.Println("Error reading confirmation:", err)
+					return
+				} else if !ok {
+					return
+				}
+
+				ids := make([]int, len(expenses))
+				for i, expense := range expenses {
+					ids[i] = expense.ID
+				}
+				if err := c.service.DeleteExpenses(cmd.Context(), ids...); err != nil {
+					fmt.Println("Error deleting expenses:", err)
+					return
+				}
+
+				fmt.Printf("%d expenses moved to the trash\n", len(ids))
+				return
+			}
+
 			id, _ := cmd.Flags().GetInt("id")
 			if err := expense.ValidateID(id); err != nil {
 				fmt.Println(err)
 				return
 			}
 
-			err := c.service.DeleteExpense(id)
+			err := c.service.DeleteExpense(cmd.Context(), id)
 			if err != nil {
 				fmt.Printf("Error deleting expense with ID %d: %v\n", id, err)
 				return
 			}
 
-			fmt.Printf("Expense with ID %d deleted successfully\n", id)
+			fmt.Printf("Expense with ID %d moved to the trash, restore it with restore --id %d\n", id, id)
 		},
 	}
 
-	deleteCmd.Flags().Int("id", 0, "Expense ID to delete (required)")
-	deleteCmd.MarkFlagRequired("id")
+	deleteCmd.Flags().Int("id", 0, "Expense ID to delete")
+	deleteCmd.Flags().Bool("where", false, "Delete all expenses matching the filter flags")
+	deleteCmd.Flags().Bool("yes", false, "Delete without asking for confirmation")
+	addFilterFlags(deleteCmd)
+	deleteCmd.MarkFlagsOneRequired("id", "where")
+	deleteCmd.MarkFlagsMutuallyExclusive("id", "where")
+	markFilterFlagsExclusive(deleteCmd, "id")
 
 	return deleteCmd
 }
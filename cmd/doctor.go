@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// printIssues prints the problems found in the stored expenses in the requested output format
+func (c *commands) printIssues(report *expense.CheckReport, output string) error {
+	switch output {
+	case "json":
+		return printJSON(report)
+	case "csv":
+		rows := make([][]string, len(report.Issues))
+		for i, issue := range report.Issues {
+			rows[i] = []string{strconv.Itoa(issue.Line), string(issue.Kind), strconv.Itoa(issue.ID), issue.Message}
+		}
+		return printCSV([]string{"line", "kind", "id", "message"}, rows)
+	}
+
+	if len(report.Issues) == 0 {
+		fmt.Printf("No problems found in %d expenses.\n", report.Rows)
+		return nil
+	}
+
+	rows := make([][]string, len(report.Issues))
+	for i, issue := range report.Issues {
+		rows[i] = []string{strconv.Itoa(issue.Line), string(issue.Kind), issue.Message}
+	}
+	c.printTable(table{
+		headers:   []string{"Line", "Problem", "Details"},
+		minWidths: []int{4, 12, 25},
+		rows:      rows,
+	})
+	fmt.Printf("%d problems found in %d rows.\n", len(report.Issues), report.Rows)
+	return nil
+}
+
+// doctorCommand creates the doctor command
+func (c *commands) doctorCommand() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the stored expenses for problems and repair them",
+		Long: "Check every row of the stored expenses for malformed columns, duplicate IDs, IDs above the ID counter\n" +
+			"and invalid categories. With --fix the bad rows are moved as they were to quarantine.txt in the data\n" +
+			"directory, so the remaining expenses can be used again, and the ID counter is raised above all IDs.",
+		Example: "expense-tracker doctor\nexpense-tracker doctor --fix",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			report, err := c.service.CheckStorage(cmd.Context())
+			if errors.Is(err, expense.ErrCheckUnsupported) {
+				fmt.Printf("The %s storage cannot be checked by doctor, only the fs storage can.\n", c.config.Storage)
+				return
+			}
+			if err != nil {
+				fmt.Println("Error checking expenses:", err)
+				return
+			}
+
+			if err := c.printIssues(report, output); err != nil {
+				fmt.Println("Error printing problems:", err)
+				return
+			}
+
+			if fix, _ := cmd.Flags().GetBool("fix"); !fix {
+				if len(report.Issues) > 0 && output == "table" {
+					fmt.Println("Run doctor --fix to quarantine the bad rows.")
+				}
+				return
+			}
+
+			repair, err := c.service.RepairStorage(cmd.Context())
+			if err != nil {
+				fmt.Println("Error repairing expenses:", err)
+				return
+			}
+			if repair.Quarantined > 0 {
+				fmt.Printf("Moved %d rows to %s\n", repair.Quarantined, repair.File)
+			}
+			if repair.LastID > 0 {
+				fmt.Printf("Raised the ID counter to %d\n", repair.LastID)
+			}
+		},
+	}
+
+	doctorCmd.Flags().Bool("fix", false, "Quarantine the bad rows and raise the ID counter")
+	addOutputFlag(doctorCmd)
+	return doctorCmd
+}
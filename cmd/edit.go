@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// editOptionsFromFlags parses the --set flags into the options editing the expenses
+func (c *commands) editOptionsFromFlags(cmd *cobra.Command) ([]expense.ExpenseOption, error) {
+	var opts []expense.ExpenseOption
+	flags := cmd.Flags()
+
+	if flags.Changed("set-category") {
+		category, _ := flags.GetString("set-category")
+		category, err := expense.ParseCategory(category)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithCategory(category))
+	}
+
+	if flags.Changed("set-description") {
+		description, _ := flags.GetString("set-description")
+		description, err := expense.ParseDescription(description)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithDescription(description))
+	}
+
+	if flags.Changed("set-amount") {
+		amount, _ := flags.GetInt("set-amount")
+		if err := expense.ValidateAmount(amount); err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithAmount(amount))
+	}
+
+	if flags.Changed("set-date") {
+		day, _ := flags.GetString("set-date")
+		date, err := parseDay(day)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithDate(date))
+	}
+
+	if flags.Changed("set-account") {
+		name, _ := flags.GetString("set-account")
+		account, err := c.accounts.Find(name)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithAccount(account.Name))
+	}
+
+	if flags.Changed("set-payee") {
+		payee, _ := flags.GetString("set-payee")
+		payee, err := expense.ParsePayee(payee)
+		if err != nil {
+			return nil, err
+		}
+		if payee, err = c.payees.Normalize(payee); err != nil {
+			return nil, err
+		}
+		opts = append(opts, expense.WithPayee(payee))
+	}
+
+	addTags, _ := flags.GetStringArray("add-tag")
+	if tags, err := expense.ParseTags(addTags); err != nil {
+		return nil, err
+	} else if len(tags) > 0 {
+		opts = append(opts, expense.WithAddedTags(tags))
+	}
+
+	removeTags, _ := flags.GetStringArray("remove-tag")
+	if tags, err := expense.ParseTags(removeTags); err != nil {
+		return nil, err
+	} else if len(tags) > 0 {
+		opts = append(opts, expense.WithoutTags(tags))
+	}
+
+	return opts, nil
+}
+
+// editCommand creates the edit command
+func (c *commands) editCommand() *cobra.Command {
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit expenses by ID or filter",
+		Long: "Change the fields given by the --set, --add-tag and --remove-tag flags of an expense by its ID,\n" +
+			"or of all expenses matching the filter flags with --where. Editing by filter previews the\n" +
+			"expenses and asks for confirmation, skipped with --yes. All changes are stored at once.",
+		Example: "expense-tracker edit --id 4 --set-amount 25\n" +
+			"expense-tracker edit --where --category Misc --search uber --set-category Transport --add-tag work",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, err := c.editOptionsFromFlags(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if len(opts) == 0 {
+				fmt.Println("Please provide the changes with the --set, --add-tag or --remove-tag flags")
+				return
+			}
+
+			var expenses []expense.Expense
+			if where, _ := cmd.Flags().GetBool("where"); where {
+				if expenses, err = c.selectWhere(cmd); err != nil {
+					fmt.Println(err)
+					return
+				}
+				if len(expenses) == 0 {
+					fmt.Println("No expenses match the filter.")
+					return
+				}
+
+				if ok, err := c.confirmBulk(cmd, expenses, "Edit"); err != nil {
+					fmt.Println("Error reading confirmation:", err)
+					return
+				} else if !ok {
+					return
+				}
+			} else {
+				id, _ := cmd.Flags().GetInt("id")
+				if err := expense.ValidateID(id); err != nil {
+					fmt.Println(err)
+					return
+				}
+
+				all, err := c.service.ListExpenses(cmd.Context())
+				if err != nil {
+					fmt.Println("Error listing expenses:", err)
+					return
+				}
+				i := slices.IndexFunc(all, func(e expense.Expense) bool { return e.ID == id })
+				if i == -1 {
+					fmt.Printf("Error editing expense with ID %d: %v\n", id, expense.ErrExpenseNotFound)
+					return
+				}
+				expenses = all[i : i+1]
+			}
+
+			changed, err := c.service.EditExpenses(cmd.Context(), expenses, opts...)
+			if err != nil {
+				fmt.Println("Error editing expenses:", err)
+				return
+			}
+
+			fmt.Printf("%d expenses edited\n", len(changed))
+		},
+	}
+
+	editCmd.Flags().Int("id", 0, "Expense ID to edit")
+	editCmd.Flags().Bool("where", false, "Edit all expenses matching the filter flags")
+	editCmd.Flags().Bool("yes", false, "Edit without asking for confirmation")
+	editCmd.Flags().String("set-category", "", "New category, not allowed for split expenses")
+	editCmd.Flags().String("set-description", "", "New description")
+	editCmd.Flags().Int("set-amount", 0, "New amount")
+	editCmd.Flags().String("set-date", "", "New date as YYYY-MM-DD")
+	editCmd.Flags().String("set-account", "", "New account, see the account command")
+	editCmd.Flags().String("set-payee", "", "New payee, normalized by the payee command")
+	editCmd.Flags().StringArray("add-tag", nil, "Tag to add, can be repeated")
+	editCmd.Flags().StringArray("remove-tag", nil, "Tag to remove, can be repeated")
+	addFilterFlags(editCmd)
+	editCmd.MarkFlagsOneRequired("id", "where")
+	editCmd.MarkFlagsMutuallyExclusive("id", "where")
+	markFilterFlagsExclusive(editCmd, "id")
+
+	return editCmd
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// addFilterFlags adds the flags selecting expenses to the command
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("account", "", "Only expenses paid with the account")
+	cmd.Flags().String("payee", "", "Only expenses paid to the payee")
+	cmd.Flags().String("tag", "", "Only expenses with the tag")
+	cmd.Flags().StringArray("meta", nil, "Only expenses with metadata key=value, or with the key if no value is given, can be repeated")
+	cmd.Flags().String("category", "", "Only expenses in the category")
+	cmd.Flags().String("from", "", "Only expenses dated on or after the day, as YYYY-MM-DD")
+	cmd.Flags().String("to", "", "Only expenses dated on or before the day, as YYYY-MM-DD")
+	cmd.Flags().String("search", "", "Only expenses whose description, payee or notes contain the text")
+}
+
+// filterFlags are the names of the flags added by addFilterFlags
+var filterFlags = []string{"account", "payee", "tag", "meta", "category", "from", "to", "search"}
+
+// markFilterFlagsExclusive makes the filter flags mutually exclusive with the flag,
+// so they are rejected instead of being silently ignored
+func markFilterFlagsExclusive(cmd *cobra.Command, flag string) {
+	for _, name := range filterFlags {
+		cmd.MarkFlagsMutuallyExclusive(flag, name)
+	}
+}
+
+// parseDay parses a day given as YYYY-MM-DD, the empty string is the zero time
+func parseDay(day string) (time.Time, error) {
+	if day == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: must be in the format YYYY-MM-DD", day)
+	}
+	return t, nil
+}
+
+// parseAsOf parses the time of a point-in-time query, either a YYYY-MM-DD day meaning its end in
+// local time or an RFC 3339 timestamp. An empty string results in the zero time.
+func parseAsOf(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, asOf); err == nil {
+		return t, nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, asOf, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: must be a date in the format YYYY-MM-DD or an RFC 3339 timestamp", asOf)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// filterFromFlags parses the flags added by [addFilterFlags] into a filter
+func filterFromFlags(cmd *cobra.Command) (expense.Filter, error) {
+	var filter expense.Filter
+	filter.Account, _ = cmd.Flags().GetString("account")
+	filter.Payee, _ = cmd.Flags().GetString("payee")
+	filter.Tag, _ = cmd.Flags().GetString("tag")
+	filter.Category, _ = cmd.Flags().GetString("category")
+	filter.Search, _ = cmd.Flags().GetString("search")
+
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	var err error
+	if filter.From, err = parseDay(from); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseDay(to); err != nil {
+		return filter, err
+	}
+
+	pairs, _ := cmd.Flags().GetStringArray("meta")
+	for _, pair := range pairs {
+		key, value := pair, ""
+		if strings.Contains(pair, "=") {
+			var err error
+			if key, value, err = expense.ParseMetadataPair(pair); err != nil {
+				return filter, err
+			}
+		}
+
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[strings.TrimSpace(key)] = value
+	}
+
+	return filter, nil
+}
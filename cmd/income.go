@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// incomeCommand creates the income command and its subcommands
+func (c *commands) incomeCommand() *cobra.Command {
+	incomeCmd := &cobra.Command{
+		Use:   "income",
+		Short: "Record money received",
+		Args:  cobra.NoArgs,
+	}
+
+	addCmd := &cobra.Command{
+		Use:     "add",
+		Short:   "Add a new income",
+		Example: "expense-tracker income add --description \"March salary\" --amount 3000 --category Salary --account Checking",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			category, _ := cmd.Flags().GetString("category")
+			category, err := expense.ParseCategory(category)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			description, _ := cmd.Flags().GetString("description")
+			if description, err = expense.ParseDescription(description); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			amount, _ := cmd.Flags().GetInt("amount")
+			if err := expense.ValidateAmount(amount); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			opts := []expense.ExpenseOption{expense.WithKind(expense.KindIncome)}
+
+			notes, _ := cmd.Flags().GetString("note")
+			if parsed, err := expense.ParseNotes(notes); err != nil {
+				fmt.Println(err)
+				return
+			} else if parsed != "" {
+				opts = append(opts, expense.WithNotes(parsed))
+			}
+
+			if name, _ := cmd.Flags().GetString("account"); name != "" {
+				account, err := c.accounts.Find(name)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				opts = append(opts, expense.WithAccount(account.Name))
+			}
+
+			added, err := c.service.AddExpense(cmd.Context(), category, description, amount, opts...)
+			if err != nil {
+				fmt.Println("Error adding income:", err)
+				return
+			}
+
+			fmt.Printf("Income added successfully (ID: %d)\n", added.ID)
+		},
+	}
+
+	addCmd.Flags().StringP("category", "c", "Income", "Income category")
+	addCmd.Flags().StringP("description", "d", "", "Income description (required)")
+	addCmd.Flags().IntP("amount", "a", 0, "Income amount (required)")
+	addCmd.Flags().StringP("note", "n", "", "Income notes, may span multiple lines")
+	addCmd.Flags().String("account", "", "Account the income was received on, see the account command")
+	addCmd.MarkFlagRequired("description")
+	addCmd.MarkFlagRequired("amount")
+	incomeCmd.AddCommand(addCmd)
+
+	return incomeCmd
+}
@@ -2,76 +2,166 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/toramanomer/expense-tracker/expense"
 )
 
-func printExpensesTable(expenses []expense.Expense) {
+// printExpenses prints the expenses in the requested output format
+func (c *commands) printExpenses(expenses []expense.Expense, output string) error {
+	switch output {
+	case "json":
+		return printJSON(expenses)
+	case "csv":
+		rows := make([][]string, len(expenses))
+		for i, expense := range expenses {
+			rows[i] = []string{
+				strconv.Itoa(expense.ID),
+				strconv.Itoa(expense.Amount),
+				expense.Category,
+				c.formatDate(expense.Date),
+				expense.Description,
+				string(expense.Kind),
+				expense.Account,
+				expense.Payee,
+				strings.Join(expense.Tags, " "),
+				formatSplits(expense.Splits),
+				expense.PaidBy,
+				formatShares(expense.Shares),
+				formatRefundOf(expense.RefundOf),
+				expense.Notes,
+				formatMetadata(expense.Metadata),
+			}
+		}
+		return printCSV([]string{"id", "amount", "category", "date", "description", "kind", "account", "payee", "tags", "splits", "paid_by", "shares", "refund_of", "notes", "metadata"}, rows)
+	default:
+		c.printExpensesTable(expenses)
+		return nil
+	}
+}
+
+// formatMetadata formats the metadata as key=value pairs sorted by key
+func formatMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		pairs = append(pairs, key+"="+metadata[key])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// formatSplits formats the splits as category=amount[:note]
+func formatSplits(splits []expense.Split) string {
+	formatted := make([]string, len(splits))
+	for i, split := range splits {
+		formatted[i] = split.Category + "=" + strconv.Itoa(split.Amount)
+		if split.Note != "" {
+			formatted[i] += ":" + split.Note
+		}
+	}
+	return strings.Join(formatted, " ")
+}
+
+// formatShares formats the shares as person=amount
+func formatShares(shares []expense.Share) string {
+	formatted := make([]string, len(shares))
+	for i, share := range shares {
+		formatted[i] = share.Person + "=" + strconv.Itoa(share.Amount)
+	}
+	return strings.Join(formatted, " ")
+}
+
+// formatRefundOf formats the ID of the refunded expense, empty if the expense is not a refund
+func formatRefundOf(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func (c *commands) printExpensesTable(expenses []expense.Expense) {
 	if len(expenses) == 0 {
 		fmt.Println("No expenses to display.")
 		return
 	}
 
-	// Define column widths
-	idWidth := 4
-	amountWidth := 8
-	categoryWidth := 12
-	dateWidth := 12
-	descWidth := 25
-
-	// Calculate dynamic widths based on content
-	for _, expense := range expenses {
-		if len(expense.Category) > categoryWidth {
-			categoryWidth = len(expense.Category)
+	rows := make([][]string, len(expenses))
+	for i, e := range expenses {
+		// Income is marked to tell it apart from money spent, refunds are shown as negative amounts
+		amount := c.formatAmount(e.NetAmount())
+		if e.Kind == expense.KindIncome {
+			amount = "+" + amount
 		}
-		if len(expense.Description) > descWidth {
-			descWidth = len(expense.Description)
+
+		rows[i] = []string{
+			strconv.Itoa(e.ID),
+			amount,
+			e.Category,
+			c.formatDate(e.Date),
+			e.Description,
 		}
 	}
 
-	// Print header
-	fmt.Printf("┌─%s─┬─%s─┬─%s─┬─%s─┬─%s─┐\n",
-		strings.Repeat("─", idWidth),
-		strings.Repeat("─", amountWidth),
-		strings.Repeat("─", categoryWidth),
-		strings.Repeat("─", dateWidth),
-		strings.Repeat("─", descWidth),
-	)
-
-	fmt.Printf("│ %-*s │ %-*s │ %-*s │ %-*s │ %-*s │\n",
-		idWidth, "ID",
-		amountWidth, "Amount",
-		categoryWidth, "Category",
-		dateWidth, "Date",
-		descWidth, "Description",
-	)
-
-	fmt.Printf("├─%s─┼─%s─┼─%s─┼─%s─┼─%s─┤\n",
-		strings.Repeat("─", idWidth),
-		strings.Repeat("─", amountWidth),
-		strings.Repeat("─", categoryWidth),
-		strings.Repeat("─", dateWidth),
-		strings.Repeat("─", descWidth),
-	)
-
-	// Print rows
-	for _, expense := range expenses {
-		// Truncate description if too long
-		desc := expense.Description
-		if len(desc) > descWidth {
-			desc = desc[:descWidth-3] + "..."
-		}
+	c.printTable(table{
+		headers:   []string{"ID", "Amount", "Category", "Date", "Description"},
+		minWidths: []int{4, 8, 12, 12, 25},
+		rows:      rows,
+	})
+}
+
+// printSkipped warns about the malformed rows skipped while listing the expenses after the table,
+// or on the standard error for machine readable output
+func printSkipped(cmd *cobra.Command, skipped []expense.Issue, output string) {
+	if len(skipped) == 0 {
+		return
+	}
 
-		fmt.Printf("│ %-*d │ $%-*d │ %-*s │ %-*s │ %-*s │\n",
-			idWidth, expense.ID,
-			amountWidth-1, expense.Amount, // -1 for the $ sign
-			categoryWidth, expense.Category,
-			dateWidth, expense.Date.Format("2006-01-02"),
-			descWidth, desc,
-		)
+	w := cmd.ErrOrStderr()
+	if output == "table" {
+		w = cmd.OutOrStdout()
 	}
+
+	fmt.Fprintf(w, "Warning: skipped %d malformed rows, run doctor --fix to quarantine them:\n", len(skipped))
+	for _, issue := range skipped {
+		fmt.Fprintf(w, "  line %d: %s\n", issue.Line, issue.Message)
+	}
+}
+
+// addAsOfFlag adds the --as-of flag selecting the point in time to list the expenses at
+func addAsOfFlag(cmd *cobra.Command) {
+	cmd.Flags().String("as-of", "", "Use the expenses as they were at the end of the day (YYYY-MM-DD) or at the RFC 3339 time")
+}
+
+// listExpensesAsOf lists the expenses as they were at the time given by the --as-of flag, the current ones without it
+func (c *commands) listExpensesAsOf(cmd *cobra.Command) ([]expense.Expense, error) {
+	asOf, _ := cmd.Flags().GetString("as-of")
+	at, err := parseAsOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	if at.IsZero() {
+		return c.service.ListExpenses(cmd.Context())
+	}
+	return c.service.ListExpensesAsOf(cmd.Context(), at)
+}
+
+// scanExpenses calls fn for each expense selected by the filter, as it was at the time given by the --as-of flag.
+// Without the flag the current expenses are streamed from the storage one at a time.
+func (c *commands) scanExpenses(cmd *cobra.Command, filter expense.Filter, fn func(expense.Expense) error) error {
+	asOf, _ := cmd.Flags().GetString("as-of")
+	at, err := parseAsOf(asOf)
+	if err != nil {
+		return err
+	}
+
+	if at.IsZero() {
+		return c.service.ScanExpenses(cmd.Context(), filter, fn)
+	}
+	return c.service.ScanExpensesAsOf(cmd.Context(), at, filter, fn)
 }
 
 // listCommand creates the list command
@@ -79,18 +169,46 @@ func (c *commands) listCommand() *cobra.Command {
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all expenses",
-		Long:  "Display all expenses in a formatted table",
+		Long:  "Display all expenses in a formatted table, or as JSON or CSV",
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			expenses, err := c.service.ListExpenses()
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			filter, err := filterFromFlags(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			var (
+				expenses []expense.Expense
+				skipped  []expense.Issue
+			)
+			if asOf, _ := cmd.Flags().GetString("as-of"); asOf != "" {
+				expenses, err = c.listExpensesAsOf(cmd)
+			} else {
+				expenses, skipped, err = c.service.ListExpensesLenient(cmd.Context())
+			}
 			if err != nil {
 				fmt.Println("Error listing expenses:", err)
 				return
 			}
+			expenses = filter.Apply(expenses)
 
-			printExpensesTable(expenses)
+			if err := c.printExpenses(expenses, output); err != nil {
+				fmt.Println("Error printing expenses:", err)
+			}
+			printSkipped(cmd, skipped, output)
 		},
 	}
 
+	addAsOfFlag(listCmd)
+	addOutputFlag(listCmd)
+	addFilterFlags(listCmd)
+
 	return listCmd
 }
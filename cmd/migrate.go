@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// migrateCommand creates the migrate command and its subcommands
+func (c *commands) migrateCommand() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the expenses to the current storage format",
+		Long: "The expenses file starts with the version of its format. Files written by earlier versions are refused\n" +
+			"by the other commands until they are upgraded with migrate run, which takes a backup first.",
+		Args: cobra.NoArgs,
+	}
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the format version of the expenses and the pending migrations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			version, pending, err := c.service.FormatStatus(cmd.Context())
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+
+			fmt.Printf("Format version: %d (current: %d)\n", version, expense.FormatVersion)
+			if len(pending) == 0 {
+				fmt.Println("No pending migrations.")
+				return
+			}
+
+			fmt.Println("Pending migrations:")
+			for _, migration := range pending {
+				fmt.Printf("  %d  %s\n", migration.Version, migration.Description)
+			}
+		},
+	})
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "run",
+		Short: "Apply the pending migrations after backing up the expenses",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			applied, backup, err := c.service.Migrate(cmd.Context())
+			if backup != "" {
+				fmt.Println("Backed up the expenses to", backup)
+			}
+			if err != nil {
+				fmt.Println("Error migrating expenses:", err)
+				return
+			}
+
+			if len(applied) == 0 {
+				fmt.Printf("Expenses are already in format version %d.\n", expense.FormatVersion)
+				return
+			}
+
+			for _, migration := range applied {
+				fmt.Printf("Migrated to format version %d: %s\n", migration.Version, migration.Description)
+			}
+		},
+	})
+
+	return migrateCmd
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/config"
+)
+
+// currencySymbols maps ISO 4217 codes to their symbols, other codes are printed as is
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"TRY": "₺",
+}
+
+// formatAmount formats the amount in the configured currency
+func (c *commands) formatAmount(amount int) string {
+	if symbol, ok := currencySymbols[c.config.Currency]; ok {
+		if amount < 0 {
+			return fmt.Sprintf("-%s%d", symbol, -amount)
+		}
+		return fmt.Sprintf("%s%d", symbol, amount)
+	}
+	return fmt.Sprintf("%d %s", amount, c.config.Currency)
+}
+
+// formatDate formats the date in the configured date format
+func (c *commands) formatDate(date time.Time) string {
+	return date.Format(c.config.DateFormat)
+}
+
+// outputFormat returns the output format requested by the --output flag, falling back to the configured one
+func (c *commands) outputFormat(cmd *cobra.Command) (string, error) {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		return c.config.Output, nil
+	}
+
+	if !slices.Contains(config.OutputFormats, output) {
+		return "", fmt.Errorf("invalid output format: must be one of %s", strings.Join(config.OutputFormats, ", "))
+	}
+	return output, nil
+}
+
+// addOutputFlag adds the --output flag to the command
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Output format, one of "+strings.Join(config.OutputFormats, ", ")+" (default from config)")
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printCSV prints the header followed by the rows as CSV
+func printCSV(header []string, rows [][]string) error {
+	writer := csv.NewWriter(os.Stdout)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	return writer.WriteAll(rows)
+}
+
+// tableBorder holds the characters used to draw a table
+type tableBorder struct {
+	fill                    string
+	topLeft, topMid, topEnd string
+	midLeft, midMid, midEnd string
+	left, mid, end          string
+}
+
+var tableBorders = map[string]tableBorder{
+	"rounded": {
+		fill:    "─",
+		topLeft: "┌─", topMid: "─┬─", topEnd: "─┐",
+		midLeft: "├─", midMid: "─┼─", midEnd: "─┤",
+		left: "│ ", mid: " │ ", end: " │",
+	},
+	"ascii": {
+		fill:    "-",
+		topLeft: "+-", topMid: "-+-", topEnd: "-+",
+		midLeft: "+-", midMid: "-+-", midEnd: "-+",
+		left: "| ", mid: " | ", end: " |",
+	},
+	"plain": {
+		mid: "  ",
+	},
+}
+
+// table describes a table to print
+type table struct {
+	headers   []string
+	minWidths []int // Minimum width of each column, optional
+	rows      [][]string
+}
+
+// printTable prints the table in the configured table style
+func (c *commands) printTable(t table) {
+	border, ok := tableBorders[c.config.TableStyle]
+	if !ok {
+		border = tableBorders["rounded"]
+	}
+
+	// Calculate dynamic widths based on content
+	widths := make([]int, len(t.headers))
+	for i, header := range t.headers {
+		widths[i] = utf8.RuneCountInString(header)
+		if i < len(t.minWidths) {
+			widths[i] = max(widths[i], t.minWidths[i])
+		}
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	line := func(left, mid, end string) {
+		if border.fill == "" {
+			return
+		}
+		fills := make([]string, len(widths))
+		for i, width := range widths {
+			fills[i] = strings.Repeat(border.fill, width)
+		}
+		fmt.Println(left + strings.Join(fills, mid) + end)
+	}
+
+	row := func(cells []string) {
+		padded := make([]string, len(widths))
+		for i, width := range widths {
+			padded[i] = fmt.Sprintf("%-*s", width, cells[i])
+		}
+		fmt.Println(strings.TrimRight(border.left+strings.Join(padded, border.mid)+border.end, " "))
+	}
+
+	line(border.topLeft, border.topMid, border.topEnd)
+	row(t.headers)
+	line(border.midLeft, border.midMid, border.midEnd)
+	for _, cells := range t.rows {
+		row(cells)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// payeeCommand creates the payee command and its subcommands
+func (c *commands) payeeCommand() *cobra.Command {
+	payeeCmd := &cobra.Command{
+		Use:   "payee",
+		Short: "Manage payees and their normalization rules",
+		Long:  "Manage the merchants and people expenses are paid to, and the patterns normalizing raw payees such as \"AMZN MKTP US*2K4\" to \"Amazon\"",
+		Args:  cobra.NoArgs,
+	}
+
+	addCmd := &cobra.Command{
+		Use:     "add",
+		Short:   "Add a payee or normalization patterns to an existing payee",
+		Example: "expense-tracker payee add --name \"Amazon\" --match \"^AMZN\" --match \"amazon\\.com\"",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if parsed, err := expense.ParsePayee(name); err != nil {
+				fmt.Println(err)
+				return
+			} else {
+				name = parsed
+			}
+
+			patterns, _ := cmd.Flags().GetStringArray("match")
+			for i, pattern := range patterns {
+				parsed, err := expense.ParsePayeePattern(pattern)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				patterns[i] = parsed
+			}
+
+			if err := c.payees.Add(name, patterns...); err != nil {
+				fmt.Println("Error adding payee:", err)
+				return
+			}
+
+			fmt.Printf("Payee %s added successfully\n", name)
+		},
+	}
+	addCmd.Flags().String("name", "", "Normalized payee name (required)")
+	addCmd.Flags().StringArray("match", nil, "Case-insensitive regular expression matching raw payees, can be repeated")
+	addCmd.MarkFlagRequired("name")
+	payeeCmd.AddCommand(addCmd)
+
+	payeeCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List all payees with their normalization patterns",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			payees, err := c.payees.List()
+			if err != nil {
+				fmt.Println("Error listing payees:", err)
+				return
+			}
+
+			if len(payees) == 0 {
+				fmt.Println("No payees to display.")
+				return
+			}
+
+			rows := make([][]string, len(payees))
+			for i, payee := range payees {
+				rows[i] = []string{payee.Name, strings.Join(payee.Patterns, "  ")}
+			}
+			c.printTable(table{headers: []string{"Name", "Patterns"}, rows: rows})
+		},
+	})
+
+	deleteCmd := &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a payee and its normalization patterns",
+		Example: "expense-tracker payee delete --name \"Amazon\"",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if err := c.payees.Delete(name); err != nil {
+				fmt.Println("Error deleting payee:", err)
+				return
+			}
+
+			fmt.Printf("Payee %s deleted successfully\n", name)
+		},
+	}
+	deleteCmd.Flags().String("name", "", "Payee name (required)")
+	deleteCmd.MarkFlagRequired("name")
+	payeeCmd.AddCommand(deleteCmd)
+
+	return payeeCmd
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// peopleCommand creates the people command and its subcommands
+func (c *commands) peopleCommand() *cobra.Command {
+	peopleCmd := &cobra.Command{
+		Use:   "people",
+		Short: "Manage the people sharing expenses",
+		Args:  cobra.NoArgs,
+	}
+
+	addCmd := &cobra.Command{
+		Use:     "add",
+		Short:   "Add a new person",
+		Example: "expense-tracker people add --name Alice",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			name, err := expense.ParsePersonName(name)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			if err := c.people.Add(name); err != nil {
+				fmt.Println("Error adding person:", err)
+				return
+			}
+
+			fmt.Printf("Person %s added successfully\n", name)
+		},
+	}
+	addCmd.Flags().String("name", "", "Name of the person (required)")
+	addCmd.MarkFlagRequired("name")
+	peopleCmd.AddCommand(addCmd)
+
+	peopleCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List all people",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			people, err := c.people.List()
+			if err != nil {
+				fmt.Println("Error listing people:", err)
+				return
+			}
+
+			if len(people) == 0 {
+				fmt.Println("No people to display.")
+				return
+			}
+
+			for _, person := range people {
+				fmt.Println(person)
+			}
+		},
+	})
+
+	deleteCmd := &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a person, expenses shared with them keep their name",
+		Example: "expense-tracker people delete --name Alice",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if err := c.people.Delete(name); err != nil {
+				fmt.Println("Error deleting person:", err)
+				return
+			}
+
+			fmt.Printf("Person %s deleted successfully\n", name)
+		},
+	}
+	deleteCmd.Flags().String("name", "", "Name of the person (required)")
+	deleteCmd.MarkFlagRequired("name")
+	peopleCmd.AddCommand(deleteCmd)
+
+	return peopleCmd
+}
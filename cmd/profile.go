@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/config"
+)
+
+// profileCommand creates the profile command and its subcommands
+func (c *commands) profileCommand() *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage separate ledgers",
+		Long: "Manage profiles, separate ledgers each with its own data directory and configuration.\n" +
+			"The active profile is chosen by --profile, then " + config.EnvPrefix + "PROFILE, then profile switch.",
+		Args: cobra.NoArgs,
+		// The profile commands do not need the storage
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.loadConfig(cmd)
+		},
+	}
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:     "create <name>",
+		Short:   "Create a new profile",
+		Example: "expense-tracker profile create business",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if err := config.CreateProfile(c.configPath, name); err != nil {
+				fmt.Println("Error creating profile:", err)
+				return
+			}
+
+			cfg, err := config.LoadProfile(c.configPath, name)
+			if err != nil {
+				fmt.Println("Error creating profile:", err)
+				return
+			}
+			dir, _, err := resolveDataDir("", cfg.DataDir, name)
+			if err != nil {
+				fmt.Println("Error creating profile:", err)
+				return
+			}
+
+			fmt.Printf("Profile %s created, its data is stored in %s\n", name, dir)
+		},
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List all profiles, marking the active one",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			profiles, err := config.Profiles(c.configPath)
+			if err != nil {
+				fmt.Println("Error listing profiles:", err)
+				return
+			}
+
+			for _, profile := range profiles {
+				if profile == c.config.Profile {
+					fmt.Println("* " + profile)
+				} else {
+					fmt.Println("  " + profile)
+				}
+			}
+		},
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:     "switch <name>",
+		Short:   "Make a profile the default one",
+		Long:    "Make a profile the default one, " + config.EnvPrefix + "PROFILE still takes precedence for the current shell",
+		Example: "expense-tracker profile switch business",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if !config.ProfileExists(c.configPath, name) {
+				fmt.Printf("Error switching profile: %v: %s\n", config.ErrProfileNotFound, name)
+				return
+			}
+
+			file, err := config.ReadFile(c.configPath)
+			if err != nil {
+				fmt.Println("Error reading configuration:", err)
+				return
+			}
+
+			file.Profile = name
+			if err := config.WriteFile(c.configPath, file); err != nil {
+				fmt.Println("Error writing configuration:", err)
+				return
+			}
+
+			fmt.Printf("Switched to profile %s\n", name)
+		},
+	})
+
+	deleteCmd := &cobra.Command{
+		Use:     "delete <name>",
+		Short:   "Delete a profile and its data",
+		Example: "expense-tracker profile delete business --yes",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if name == c.config.Profile {
+				fmt.Println("Error deleting profile: cannot delete the active profile")
+				return
+			}
+
+			if !config.ProfileExists(c.configPath, name) {
+				fmt.Printf("Error deleting profile: %v: %s\n", config.ErrProfileNotFound, name)
+				return
+			}
+
+			file, err := config.ParseFile(config.ProfilePath(c.configPath, name))
+			if err != nil {
+				fmt.Println("Error reading configuration:", err)
+				return
+			}
+
+			// Only the directory created for the profile is removed, a directory chosen by the user may hold anything
+			dir, explicit, err := resolveDataDir("", file.DataDir, name)
+			if err != nil {
+				fmt.Println("Error deleting profile:", err)
+				return
+			}
+
+			if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+				if explicit {
+					fmt.Printf("Deleting profile %s removes its configuration and keeps its data in %s, rerun with --yes to confirm\n", name, dir)
+				} else {
+					fmt.Printf("Deleting profile %s removes all of its data in %s, rerun with --yes to confirm\n", name, dir)
+				}
+				return
+			}
+
+			if err := config.DeleteProfile(c.configPath, name); err != nil {
+				fmt.Println("Error deleting profile:", err)
+				return
+			}
+
+			if explicit {
+				fmt.Printf("Profile %s deleted, its data in %s is kept\n", name, dir)
+				return
+			}
+
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Println("Error deleting profile data:", err)
+				return
+			}
+
+			fmt.Printf("Profile %s deleted\n", name)
+		},
+	}
+	deleteCmd.Flags().Bool("yes", false, "Confirm deleting the profile and its data")
+	profileCmd.AddCommand(deleteCmd)
+
+	return profileCmd
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// stdin is shared by all prompts so input buffered by one prompt is not lost to the next one,
+// replaced in tests to type the answers
+var stdin = bufio.NewReader(os.Stdin)
+
+// isTerminal reports whether f is connected to a terminal, replaced in tests to exercise the prompts
+var isTerminal = func(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// prompt prints the question and returns the trimmed line typed by the user
+func prompt(question string) (string, error) {
+	fmt.Print(question)
+	return readLine()
+}
+
+// readLine returns the trimmed line typed by the user
+func readLine() (string, error) {
+	answer, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimSpace(answer), nil
+}
+
+// confirm asks the question with a yes/no prompt and reports whether the user agreed.
+// Without a terminal to ask on, the user is told to confirm with --yes instead.
+func confirm(question string) (bool, error) {
+	if !isTerminal(os.Stdin) {
+		fmt.Println("Rerun with --yes to confirm.")
+		return false, nil
+	}
+
+	answer, err := prompt(question + " [y/N] ")
+	if err != nil {
+		return false, err
+	}
+
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
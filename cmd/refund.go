@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// refundCommand creates the refund command
+func (c *commands) refundCommand() *cobra.Command {
+	refundCmd := &cobra.Command{
+		Use:     "refund",
+		Short:   "Refund part or all of an expense",
+		Long:    "Add a refund of an expense, reducing the totals of its category. The refunds of an expense cannot exceed its amount.",
+		Example: "expense-tracker refund --id 3 --amount 20 --description \"Returned shoes\"",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			id, _ := cmd.Flags().GetInt("id")
+			if err := expense.ValidateID(id); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			amount, _ := cmd.Flags().GetInt("amount")
+			if err := expense.ValidateAmount(amount); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			description, _ := cmd.Flags().GetString("description")
+			if description == "" {
+				description = fmt.Sprintf("Refund of expense %d", id)
+			}
+			description, err := expense.ParseDescription(description)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			refund, err := c.service.RefundExpense(cmd.Context(), id, amount, description)
+			if err != nil {
+				fmt.Printf("Error refunding expense with ID %d: %v\n", id, err)
+				return
+			}
+
+			fmt.Printf("Refund added successfully (ID: %d)\n", refund.ID)
+		},
+	}
+
+	refundCmd.Flags().Int("id", 0, "ID of the expense to refund (required)")
+	refundCmd.Flags().IntP("amount", "a", 0, "Refunded amount (required)")
+	refundCmd.Flags().StringP("description", "d", "", "Refund description")
+	refundCmd.MarkFlagRequired("id")
+	refundCmd.MarkFlagRequired("amount")
+
+	return refundCmd
+}
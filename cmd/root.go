@@ -1,20 +1,117 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/config"
 	"github.com/toramanomer/expense-tracker/expense"
 )
 
 // commands holds the dependencies for all commands
 type commands struct {
-	service *expense.ExpenseService
+	config      *config.Config
+	configPath  string
+	service     *expense.ExpenseService
+	accounts    *expense.AccountRegistry
+	payees      *expense.PayeeRegistry
+	people      *expense.PeopleRegistry
+	settlements *expense.SettlementLedger
+	rules       *expense.RulesFS
+	auditLog    *expense.AuditLogFS
+	dataDir     string
+	configErr   error // Error loading the configuration, only tolerated by the config command
+}
+
+// settingsPath returns the configuration file of the active profile
+func (c *commands) settingsPath() string {
+	return config.ProfilePath(c.configPath, c.config.Profile)
 }
 
-// NewCommands creates a new Commands instance with the provided service
-func NewCommands(service *expense.ExpenseService) *commands {
+// NewCommands creates a new Commands instance with the path of the configuration file.
+// The configuration and the remaining dependencies are loaded before running a command once the global flags are parsed.
+func NewCommands(configPath string) *commands {
 	return &commands{
-		service: service,
+		configPath: configPath,
+	}
+}
+
+// loadConfig loads the configuration of the profile selected by the --profile flag, the active one by default
+func (c *commands) loadConfig(cmd *cobra.Command) error {
+	profile, _ := cmd.Flags().GetString("profile")
+	cfg, err := config.LoadProfile(c.configPath, profile)
+	if err != nil {
+		return fmt.Errorf("loading configuration: %w", err)
+	}
+
+	c.config = cfg
+	return nil
+}
+
+// needsStorage reports whether the command reads or writes the data directory, which help and completion never do
+func needsStorage(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		switch cmd.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+	}
+	return true
+}
+
+// init resolves the profile and the data directory and initializes the dependencies of the commands
+func (c *commands) init(cmd *cobra.Command) error {
+	if err := c.loadConfig(cmd); err != nil {
+		return err
+	}
+	if !needsStorage(cmd) {
+		return nil
+	}
+
+	flag, _ := cmd.Flags().GetString("data-dir")
+	dir, explicit, err := resolveDataDir(flag, c.config.DataDir, c.config.Profile)
+	if err != nil {
+		return err
+	}
+
+	// Earlier versions had no profiles, so only the default profile can hold their data
+	if !explicit && c.config.Profile == config.DefaultProfile {
+		if err := offerLegacyMigration(dir, cmd.OutOrStdout()); err != nil {
+			return err
+		}
 	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating the data directory: %w", err)
+	}
+
+	var storage expense.ExpenseStorage
+	switch c.config.Storage {
+	case "fs":
+		storage = expense.NewStorageFS(dir)
+	case "events":
+		storage = expense.NewStorageEvents(dir)
+		if err := offerEventsImport(cmd.Context(), dir, storage, cmd.OutOrStdout()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown storage backend: %s", c.config.Storage)
+	}
+
+	c.dataDir = dir
+	c.rules = expense.NewRulesFS(dir)
+	c.auditLog = expense.NewAuditLogFS(dir, expense.CurrentActor(os.Args))
+	c.service = expense.NewExpenseService(storage,
+		expense.WithRules(c.rules),
+		expense.WithJournal(expense.NewJournalFS(dir)),
+		expense.WithAuditLog(c.auditLog),
+	)
+	c.accounts = expense.NewAccountRegistry(dir)
+	c.payees = expense.NewPayeeRegistry(dir)
+	c.people = expense.NewPeopleRegistry(dir)
+	c.settlements = expense.NewSettlementLedger(dir)
+	return nil
 }
 
 // RootCommand creates and returns the root command with all subcommands
@@ -23,13 +120,40 @@ func (c *commands) RootCommand() *cobra.Command {
 		Use:   "expense-tracker",
 		Short: "A simple expense tracker CLI application to manage your finances.",
 		Long:  "A command-line application to track your expenses, manage budgets, and generate reports.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.init(cmd)
+		},
 	}
 
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "Profile to use (default from "+config.EnvPrefix+"PROFILE or profile switch)")
+	rootCmd.PersistentFlags().String("data-dir", "", "Directory to store the data in (default $XDG_DATA_HOME/expense-tracker, env "+config.EnvPrefix+"DATA_DIR)")
+
 	// Add all subcommands
 	rootCmd.AddCommand(c.addCommand())
+	rootCmd.AddCommand(c.incomeCommand())
+	rootCmd.AddCommand(c.refundCommand())
+	rootCmd.AddCommand(c.editCommand())
 	rootCmd.AddCommand(c.deleteCommand())
+	rootCmd.AddCommand(c.trashCommand())
+	rootCmd.AddCommand(c.undoCommand())
+	rootCmd.AddCommand(c.redoCommand())
+	rootCmd.AddCommand(c.auditCommand())
 	rootCmd.AddCommand(c.listCommand())
+	rootCmd.AddCommand(c.showCommand())
 	rootCmd.AddCommand(c.summaryCommand())
+	rootCmd.AddCommand(c.accountCommand())
+	rootCmd.AddCommand(c.payeeCommand())
+	rootCmd.AddCommand(c.peopleCommand())
+	rootCmd.AddCommand(c.balancesCommand())
+	rootCmd.AddCommand(c.settleCommand())
+	rootCmd.AddCommand(c.rulesCommand())
+	rootCmd.AddCommand(c.recategorizeCommand())
+	rootCmd.AddCommand(c.backupCommand())
+	rootCmd.AddCommand(c.restoreCommand())
+	rootCmd.AddCommand(c.migrateCommand())
+	rootCmd.AddCommand(c.doctorCommand())
+	rootCmd.AddCommand(c.configCommand())
+	rootCmd.AddCommand(c.profileCommand())
 
 	return rootCmd
 }
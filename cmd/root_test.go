@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toramanomer/expense-tracker/config"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// execute runs the root command with the arguments and a configuration file in a temporary directory
+func execute(t *testing.T, args ...string) error {
+	t.Helper()
+	return executeWith(t, filepath.Join(t.TempDir(), "config.yaml"), args...)
+}
+
+// executeWith runs the root command with the arguments and the configuration file
+func executeWith(t *testing.T, configPath string, args ...string) error {
+	t.Helper()
+	c := NewCommands(configPath)
+	root := c.RootCommand()
+	root.SetArgs(args)
+	root.SetIn(os.Stdin)
+	root.SetOut(io.Discard)
+	return root.Execute()
+}
+
+// typeAnswer makes the prompts read the answer as if it was typed by the user
+func typeAnswer(t *testing.T, answer string) {
+	t.Helper()
+	original := stdin
+	stdin = bufio.NewReader(strings.NewReader(answer + "\n"))
+	t.Cleanup(func() { stdin = original })
+}
+
+func TestRootCommand_DataDir(t *testing.T) {
+	// A data directory below a regular file cannot be created
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	dir := filepath.Join(file, "data")
+
+	assert.NoError(t, execute(t, "--data-dir", dir, "help"), "help does not touch the data directory")
+	assert.NoError(t, execute(t, "--data-dir", dir, "completion", "bash"))
+	assert.ErrorContains(t, execute(t, "--data-dir", dir, "list"), "creating the data directory")
+}
+
+func TestRootCommand_InvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("currency: EUR\ndate_format: \"%%%\"\n"), 0644))
+	dir := t.TempDir()
+
+	assert.ErrorContains(t, executeWith(t, path, "--data-dir", dir, "list"), "invalid date_format")
+
+	require.NoError(t, executeWith(t, path, "config", "set", "date_format", "02.01.2006"))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "02.01.2006", cfg.DateFormat)
+	assert.Equal(t, "EUR", cfg.Currency, "the other values are kept")
+
+	assert.NoError(t, executeWith(t, path, "--data-dir", dir, "list"))
+}
+
+func TestRootCommand_Profiles(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	global := t.TempDir()
+	require.NoError(t, config.WriteFile(path, &config.Config{DataDir: global}))
+
+	require.NoError(t, executeWith(t, path, "profile", "create", "work"))
+	require.NoError(t, executeWith(t, path, "-p", "work", "add", "--category", "Food", "--description", "Lunch", "--amount", "10"))
+	assert.NoFileExists(t, filepath.Join(global, "expenses.txt"), "the profile does not write to the global data directory")
+	work := filepath.Join(os.Getenv("XDG_DATA_HOME"), "expense-tracker", "profiles", "work")
+	assert.FileExists(t, filepath.Join(work, "expenses.txt"))
+
+	t.Run("delete keeps an explicit data directory", func(t *testing.T) {
+		shared := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(shared, "keep.txt"), nil, 0644))
+		require.NoError(t, executeWith(t, path, "profile", "create", "shared"))
+		require.NoError(t, config.WriteFile(config.ProfilePath(path, "shared"), &config.Config{DataDir: shared}))
+
+		require.NoError(t, executeWith(t, path, "profile", "delete", "shared", "--yes"))
+		assert.False(t, config.ProfileExists(path, "shared"))
+		assert.FileExists(t, filepath.Join(shared, "keep.txt"))
+	})
+
+	t.Run("delete removes the profile directory", func(t *testing.T) {
+		require.NoError(t, executeWith(t, path, "profile", "delete", "work", "--yes"))
+		assert.NoDirExists(t, work)
+		assert.DirExists(t, global)
+	})
+}
+
+func TestRootCommand_IDWithFilters(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, execute(t, "--data-dir", dir, "add", "--category", "Food", "--description", "Lunch", "--amount", "10"))
+
+	assert.ErrorContains(t, execute(t, "--data-dir", dir, "delete", "--id", "1", "--category", "Food"), "none of the others can be")
+	assert.ErrorContains(t, execute(t, "--data-dir", dir, "edit", "--id", "1", "--from", "2025-01-01", "--set-amount", "5"), "none of the others can be")
+	assert.NoError(t, execute(t, "--data-dir", dir, "delete", "--where", "--category", "Food", "--yes"))
+}
+
+func TestRootCommand_MigrateRun(t *testing.T) {
+	dir := t.TempDir()
+	legacy := "1,10,Food,Lunch,2025-04-20,{}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(legacy), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ids.txt"), []byte("1"), 0644))
+
+	require.NoError(t, execute(t, "--data-dir", dir, "list"))
+	assertFile(t, filepath.Join(dir, "expenses.txt"), legacy)
+
+	require.NoError(t, execute(t, "--data-dir", dir, "migrate", "run"))
+	require.NoError(t, execute(t, "--data-dir", dir, "add", "--category", "Food", "--description", "Dinner", "--amount", "20"))
+	assertFile(t, filepath.Join(dir, "expenses.txt"), "#expense-tracker format 2\n1,10,Food,Lunch,2025-04-20\n"+
+		"2,20,Food,Dinner,"+time.Now().Format(time.DateOnly)+"\n")
+
+	entries, err := expense.NewAuditLogFS(dir, expense.Actor{}).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, expense.OpMigrate, entries[0].Op, "the upgrade is audited")
+}
+
+func TestRootCommand_UndoRedo(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, execute(t, "--data-dir", dir, "add", "--category", "Food", "--description", "Lunch", "--amount", "10"))
+
+	require.NoError(t, execute(t, "--data-dir", dir, "undo"))
+	expenses, err := expense.NewStorageFS(dir).List(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, expenses)
+
+	require.NoError(t, execute(t, "--data-dir", dir, "redo"))
+	expenses, err = expense.NewStorageFS(dir).List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, "Lunch", expenses[0].Description)
+}
+
+func TestRootCommand_AddChoosesCategory(t *testing.T) {
+	defer func(original func(*os.File) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(*os.File) bool { return true }
+	dir := t.TempDir()
+
+	// Without suggestions a number is taken as the category
+	typeAnswer(t, "42")
+	require.NoError(t, execute(t, "--data-dir", dir, "add", "--description", "Lunch", "--amount", "10"))
+
+	typeAnswer(t, "1")
+	require.NoError(t, execute(t, "--data-dir", dir, "add", "--description", "Lunch", "--amount", "12"))
+
+	expenses, err := expense.NewStorageFS(dir).List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, expenses, 2)
+	assert.Equal(t, "42", expenses[0].Category)
+	assert.Equal(t, "42", expenses[1].Category, "the first suggestion is chosen by its number")
+}
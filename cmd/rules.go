@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// rulesCommand creates the rules command and its subcommands
+func (c *commands) rulesCommand() *cobra.Command {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect the rules categorizing expenses",
+		Long: `Inspect the rules categorizing expenses added without a category.
+Rules are read from rules.yaml in the data directory and the first matching rule applies, e.g.
+
+rules:
+  - name: coffee
+    match:
+      description: latte|espresso
+      max_amount: 10
+      weekdays: [mon, tue, wed, thu, fri]
+    set:
+      category: Coffee
+      tags: [caffeine]
+      payee: Starbucks`,
+		Args: cobra.NoArgs,
+	}
+
+	rulesCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the rules in the order they are matched",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rules, err := c.rules.Rules()
+			if err != nil {
+				fmt.Println("Error reading rules:", err)
+				return
+			}
+
+			if len(rules) == 0 {
+				fmt.Printf("No rules to display, add them to %s\n", c.rules.Path())
+				return
+			}
+
+			rows := make([][]string, len(rules))
+			for i, rule := range rules {
+				rows[i] = []string{rule.Name, rule.Set.Category, strings.Join(rule.Set.Tags, " "), rule.Set.Payee}
+			}
+			c.printTable(table{headers: []string{"Name", "Category", "Tags", "Payee"}, rows: rows})
+		},
+	})
+
+	testCmd := &cobra.Command{
+		Use:     "test",
+		Short:   "Show which rule matches each expense",
+		Example: "expense-tracker rules test --payee Starbucks",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := filterFromFlags(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			expenses, err := c.service.ListExpenses(cmd.Context())
+			if err != nil {
+				fmt.Println("Error listing expenses:", err)
+				return
+			}
+
+			matches, err := c.service.MatchRules(filter.Apply(expenses))
+			if err != nil {
+				fmt.Println("Error reading rules:", err)
+				return
+			}
+
+			if len(matches) == 0 {
+				fmt.Println("No expenses to display.")
+				return
+			}
+
+			rows := make([][]string, len(matches))
+			for i, match := range matches {
+				rule, category := "-", "-"
+				if match.Rule != nil {
+					rule, category = match.Rule.Name, match.Rule.Set.Category
+				}
+				rows[i] = []string{
+					strconv.Itoa(match.Expense.ID),
+					match.Expense.Description,
+					match.Expense.Category,
+					rule,
+					category,
+				}
+			}
+			c.printTable(table{headers: []string{"ID", "Description", "Category", "Rule", "Rule Category"}, rows: rows})
+		},
+	}
+	addFilterFlags(testCmd)
+	rulesCmd.AddCommand(testCmd)
+
+	return rulesCmd
+}
+
+// recategorizeCommand creates the recategorize command
+func (c *commands) recategorizeCommand() *cobra.Command {
+	recategorizeCmd := &cobra.Command{
+		Use:     "recategorize",
+		Short:   "Re-run the rules over existing expenses",
+		Long:    "Apply the first matching rule to each existing expense, updating its category, tags and payee",
+		Example: "expense-tracker recategorize --dry-run",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := filterFromFlags(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			expenses, err := c.service.ListExpenses(cmd.Context())
+			if err != nil {
+				fmt.Println("Error listing expenses:", err)
+				return
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			changed, err := c.service.Recategorize(cmd.Context(), filter.Apply(expenses), dryRun)
+			if err != nil {
+				fmt.Println("Error recategorizing expenses:", err)
+				return
+			}
+
+			if len(changed) == 0 {
+				fmt.Println("No expenses changed.")
+				return
+			}
+
+			c.printExpensesTable(changed)
+			if dryRun {
+				fmt.Printf("%d expenses would be recategorized\n", len(changed))
+			} else {
+				fmt.Printf("%d expenses recategorized successfully\n", len(changed))
+			}
+		},
+	}
+
+	recategorizeCmd.Flags().Bool("dry-run", false, "Only show the expenses which would change")
+	addFilterFlags(recategorizeCmd)
+
+	return recategorizeCmd
+}
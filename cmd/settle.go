@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// balances computes the net balance of each person from the shared expenses and the settlements
+func (c *commands) balances(ctx context.Context) ([]expense.Balance, error) {
+	expenses, err := c.service.ListExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements, err := c.settlements.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return expense.Balances(expenses, settlements), nil
+}
+
+// balancesCommand creates the balances command
+func (c *commands) balancesCommand() *cobra.Command {
+	balancesCmd := &cobra.Command{
+		Use:   "balances",
+		Short: "Show who owes whom across shared expenses",
+		Long:  "Show the net balance of each person across shared expenses and settlements, a positive balance is owed to the person",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			balances, err := c.balances(cmd.Context())
+			if err != nil {
+				fmt.Println("Error computing balances:", err)
+				return
+			}
+
+			switch output {
+			case "json":
+				err = printJSON(balances)
+			case "csv":
+				rows := make([][]string, len(balances))
+				for i, balance := range balances {
+					rows[i] = []string{balance.Person, strconv.Itoa(balance.Net)}
+				}
+				err = printCSV([]string{"person", "net"}, rows)
+			default:
+				if len(balances) == 0 {
+					fmt.Println("Everyone is settled up.")
+					return
+				}
+
+				rows := make([][]string, len(balances))
+				for i, balance := range balances {
+					status := "is owed"
+					if balance.Net < 0 {
+						status = "owes"
+					}
+					rows[i] = []string{balance.Person, c.formatAmount(balance.Net), status}
+				}
+				c.printTable(table{headers: []string{"Person", "Net", "Status"}, minWidths: []int{12, 8}, rows: rows})
+			}
+			if err != nil {
+				fmt.Println("Error printing balances:", err)
+			}
+		},
+	}
+
+	addOutputFlag(balancesCmd)
+
+	return balancesCmd
+}
+
+// settleCommand creates the settle command
+func (c *commands) settleCommand() *cobra.Command {
+	settleCmd := &cobra.Command{
+		Use:   "settle",
+		Short: "Record settlements and propose transfers settling all debts",
+		Long: "Without flags, propose the fewest transfers settling all balances.\n" +
+			"Record a payment between two people with --from, --to and --amount, or all proposed transfers with --all.",
+		Example: "expense-tracker settle\n" +
+			"expense-tracker settle --from Bob --to Alice --amount 20\n" +
+			"expense-tracker settle --all",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+			amount, _ := cmd.Flags().GetInt("amount")
+			all, _ := cmd.Flags().GetBool("all")
+
+			if from != "" || to != "" || cmd.Flags().Changed("amount") {
+				if all {
+					fmt.Println("Please provide either --all or --from, --to and --amount")
+					return
+				}
+				if from == "" || to == "" {
+					fmt.Println("Please provide both --from and --to to record a settlement")
+					return
+				}
+
+				var err error
+				if from, err = c.people.Find(from); err != nil {
+					fmt.Println(err)
+					return
+				}
+				if to, err = c.people.Find(to); err != nil {
+					fmt.Println(err)
+					return
+				}
+
+				settlement := expense.Settlement{Date: time.Now(), From: from, To: to, Amount: amount}
+				if err := c.settlements.Add(settlement); err != nil {
+					fmt.Println("Error recording settlement:", err)
+					return
+				}
+
+				fmt.Printf("Recorded %s paying %s %s\n", from, to, c.formatAmount(amount))
+				return
+			}
+
+			balances, err := c.balances(cmd.Context())
+			if err != nil {
+				fmt.Println("Error computing balances:", err)
+				return
+			}
+
+			transfers := expense.SettleUp(balances)
+			if len(transfers) == 0 {
+				fmt.Println("Everyone is settled up.")
+				return
+			}
+
+			if !all {
+				fmt.Println("Proposed transfers:")
+				for _, transfer := range transfers {
+					fmt.Printf("  %s pays %s %s\n", transfer.From, transfer.To, c.formatAmount(transfer.Amount))
+				}
+				fmt.Println("Rerun with --all to record them.")
+				return
+			}
+
+			settlements := make([]expense.Settlement, len(transfers))
+			for i, transfer := range transfers {
+				settlements[i] = expense.Settlement{Date: time.Now(), From: transfer.From, To: transfer.To, Amount: transfer.Amount}
+			}
+			if err := c.settlements.Add(settlements...); err != nil {
+				fmt.Println("Error recording settlements:", err)
+				return
+			}
+
+			for _, transfer := range transfers {
+				fmt.Printf("Recorded %s paying %s %s\n", transfer.From, transfer.To, c.formatAmount(transfer.Amount))
+			}
+		},
+	}
+
+	settleCmd.Flags().String("from", "", "Person who paid")
+	settleCmd.Flags().String("to", "", "Person who was paid")
+	settleCmd.Flags().Int("amount", 0, "Amount paid")
+	settleCmd.Flags().Bool("all", false, "Record all proposed transfers")
+
+	return settleCmd
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// expenseDetails is an expense with its refunds
+type expenseDetails struct {
+	expense.Expense
+	Refunds []expense.Expense `json:"refunds,omitempty"`
+}
+
+// printExpenseDetails prints all fields of the expense followed by its refunds
+func (c *commands) printExpenseDetails(details expenseDetails) {
+	e := details.Expense
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("%-12s %s\n", name+":", value)
+		}
+	}
+
+	field("ID", strconv.Itoa(e.ID))
+	field("Amount", c.formatAmount(e.NetAmount()))
+	field("Category", e.Category)
+	field("Date", c.formatDate(e.Date))
+	field("Description", e.Description)
+	field("Kind", string(e.Kind))
+	if e.IsRefund() {
+		field("Refund of", strconv.Itoa(e.RefundOf))
+	}
+	field("Account", e.Account)
+	field("Payee", e.Payee)
+	field("Tags", strings.Join(e.Tags, " "))
+	field("Splits", formatSplits(e.Splits))
+	field("Paid by", e.PaidBy)
+	field("Shares", formatShares(e.Shares))
+	field("Metadata", formatMetadata(e.Metadata))
+	if e.Notes != "" {
+		fmt.Println("Notes:")
+		for _, line := range strings.Split(e.Notes, "\n") {
+			fmt.Println("  " + line)
+		}
+	}
+
+	if len(details.Refunds) == 0 {
+		return
+	}
+
+	fmt.Println("Refunds:")
+	var refunded int
+	for _, refund := range details.Refunds {
+		refunded += refund.Amount
+		fmt.Printf("  %d  %s  %s  %s\n", refund.ID, c.formatAmount(-refund.Amount), c.formatDate(refund.Date), refund.Description)
+	}
+	fmt.Printf("Net amount:  %s\n", c.formatAmount(e.Amount-refunded))
+}
+
+// showCommand creates the show command
+func (c *commands) showCommand() *cobra.Command {
+	showCmd := &cobra.Command{
+		Use:     "show",
+		Short:   "Show all details of an expense",
+		Long:    "Show all fields of an expense with its refunds nested under it",
+		Example: "expense-tracker show --id 3",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			id, _ := cmd.Flags().GetInt("id")
+			if err := expense.ValidateID(id); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			expenses, err := c.service.ListExpenses(cmd.Context())
+			if err != nil {
+				fmt.Println("Error listing expenses:", err)
+				return
+			}
+
+			i := slices.IndexFunc(expenses, func(e expense.Expense) bool { return e.ID == id })
+			if i == -1 {
+				fmt.Printf("Error showing expense with ID %d: %v\n", id, expense.ErrExpenseNotFound)
+				return
+			}
+			details := expenseDetails{Expense: expenses[i], Refunds: expense.Refunds(expenses, id)}
+
+			switch output {
+			case "json":
+				err = printJSON(details)
+			case "csv":
+				err = c.printExpenses(append([]expense.Expense{details.Expense}, details.Refunds...), output)
+			default:
+				c.printExpenseDetails(details)
+			}
+			if err != nil {
+				fmt.Println("Error printing expense:", err)
+			}
+		},
+	}
+
+	showCmd.Flags().Int("id", 0, "Expense ID to show (required)")
+	showCmd.MarkFlagRequired("id")
+	addOutputFlag(showCmd)
+
+	return showCmd
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// importDeclinedFile marks a data directory whose owner declined importing the fs storage into the events storage
+const importDeclinedFile = ".events-import-declined"
+
+// offerEventsImport asks once to import the expenses of the fs storage in dir into the events storage,
+// which would otherwise silently start empty after switching the backend. The offer is only made on a
+// terminal while the events storage is empty; a declined offer is remembered.
+func offerEventsImport(ctx context.Context, dir string, events expense.ExpenseStorage, out io.Writer) error {
+	if !hasExpenseData(dir) || !isTerminal(os.Stdin) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, importDeclinedFile)); err == nil {
+		return nil
+	}
+	if lastID, err := events.LastID(ctx); err != nil || lastID != 0 {
+		return err
+	}
+
+	fs := expense.NewStorageFS(dir)
+	expenses, err := fs.List(ctx)
+	if err != nil {
+		return fmt.Errorf("reading expenses.txt to import: %w", err)
+	}
+
+	fmt.Fprintf(out, "The events storage is empty but expenses.txt of the fs storage holds %d expenses. Import them? [y/N] ", len(expenses))
+	answer, err := readLine()
+	if err != nil {
+		return err
+	}
+
+	if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+		fmt.Fprintln(out, "Starting with no expenses, expenses.txt is kept and used again with config set storage fs.")
+		return os.WriteFile(filepath.Join(dir, importDeclinedFile), nil, 0644)
+	}
+
+	n, err := expense.ImportStorage(ctx, fs, events)
+	if err != nil {
+		return fmt.Errorf("importing expenses.txt: %w", err)
+	}
+	fmt.Fprintf(out, "Imported %d expenses, expenses.txt is kept but no longer updated\n", n)
+	return nil
+}
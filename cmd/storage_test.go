@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+func TestOfferEventsImport(t *testing.T) {
+	defer func(original func(*os.File) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(*os.File) bool { return true }
+
+	// newDir returns a data directory holding two expenses of the fs storage, one of them in the trash
+	newDir := func(t *testing.T) string {
+		dir := t.TempDir()
+		require.NoError(t, execute(t, "--data-dir", dir, "add", "--category", "Food", "--description", "Lunch", "--amount", "10"))
+		require.NoError(t, execute(t, "--data-dir", dir, "add", "--category", "Food", "--description", "Dinner", "--amount", "20"))
+		require.NoError(t, execute(t, "--data-dir", dir, "delete", "--id", "1"))
+		return dir
+	}
+
+	t.Run("import", func(t *testing.T) {
+		dir := newDir(t)
+		events := expense.NewStorageEvents(dir)
+		var out bytes.Buffer
+		typeAnswer(t, "y")
+		require.NoError(t, offerEventsImport(t.Context(), dir, events, &out))
+		assert.Contains(t, out.String(), "holds 1 expenses")
+
+		expenses, err := events.List(t.Context())
+		require.NoError(t, err)
+		require.Len(t, expenses, 1)
+		assert.Equal(t, "Dinner", expenses[0].Description)
+		trash, err := events.Trash(t.Context())
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, 1, trash[0].ID)
+		lastID, err := events.LastID(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 2, lastID)
+
+		out.Reset()
+		typeAnswer(t, "y")
+		require.NoError(t, offerEventsImport(t.Context(), dir, events, &out))
+		assert.Empty(t, out.String(), "no offer once the events storage holds data")
+	})
+
+	t.Run("later answers are left to the next prompt", func(t *testing.T) {
+		dir := newDir(t)
+		typeAnswer(t, "y\nFood")
+		require.NoError(t, offerEventsImport(t.Context(), dir, expense.NewStorageEvents(dir), &bytes.Buffer{}))
+
+		answer, err := readLine()
+		require.NoError(t, err)
+		assert.Equal(t, "Food", answer)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		dir := newDir(t)
+		events := expense.NewStorageEvents(dir)
+		typeAnswer(t, "n")
+		require.NoError(t, offerEventsImport(t.Context(), dir, events, &bytes.Buffer{}))
+		assert.FileExists(t, filepath.Join(dir, importDeclinedFile))
+
+		var out bytes.Buffer
+		typeAnswer(t, "y")
+		require.NoError(t, offerEventsImport(t.Context(), dir, events, &out))
+		assert.Empty(t, out.String(), "a declined offer is not made again")
+		expenses, err := events.List(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, expenses)
+	})
+}
@@ -2,17 +2,126 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
 )
 
+// printGroupTotals prints the totals of the groups in the requested output format
+func (c *commands) printGroupTotals(totals []expense.GroupTotal, by expense.GroupBy, output string) error {
+	switch output {
+	case "json":
+		return printJSON(totals)
+	case "csv":
+		rows := make([][]string, len(totals))
+		for i, total := range totals {
+			rows[i] = []string{total.Group, strconv.Itoa(total.Total), strconv.Itoa(total.Count)}
+		}
+		return printCSV([]string{string(by), "total", "count"}, rows)
+	}
+
+	if len(totals) == 0 {
+		fmt.Println("No expenses to summarize.")
+		return nil
+	}
+
+	rows := make([][]string, len(totals))
+	for i, total := range totals {
+		group := total.Group
+		if group == "" {
+			group = "(none)"
+		}
+		rows[i] = []string{group, c.formatAmount(total.Total), strconv.Itoa(total.Count)}
+	}
+
+	c.printTable(table{
+		headers:   []string{strings.ToUpper(string(by[:1])) + string(by[1:]), "Total", "Count"},
+		minWidths: []int{12, 8, 5},
+		rows:      rows,
+	})
+	return nil
+}
+
+// weekStart returns the configured first day of the week
+func (c *commands) weekStart() time.Weekday {
+	if c.config.WeekStart == "sunday" {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// formatPeriod formats the period starting at start
+func (c *commands) formatPeriod(start time.Time, period expense.Period) string {
+	switch period {
+	case expense.PeriodWeek:
+		return "Week of " + c.formatDate(start)
+	case expense.PeriodYear:
+		return strconv.Itoa(start.Year())
+	default:
+		return start.Format("January 2006")
+	}
+}
+
+// printCashflow prints the cash flow of the periods in the requested output format
+func (c *commands) printCashflow(periods []expense.CashflowPeriod, period expense.Period, output string) error {
+	switch output {
+	case "json":
+		return printJSON(periods)
+	case "csv":
+		rows := make([][]string, len(periods))
+		for i, p := range periods {
+			rows[i] = []string{
+				p.Start.Format(time.DateOnly),
+				strconv.Itoa(p.Income),
+				strconv.Itoa(p.Expenses),
+				strconv.Itoa(p.Net),
+				strconv.FormatFloat(p.SavingsRate, 'f', 4, 64),
+			}
+		}
+		return printCSV([]string{"start", "income", "expenses", "net", "savings_rate"}, rows)
+	}
+
+	if len(periods) == 0 {
+		fmt.Println("No income or expenses to summarize.")
+		return nil
+	}
+
+	rows := make([][]string, len(periods))
+	for i, p := range periods {
+		rate := "-"
+		if p.Income > 0 {
+			rate = fmt.Sprintf("%.0f%%", p.SavingsRate*100)
+		}
+		rows[i] = []string{c.formatPeriod(p.Start, period), c.formatAmount(p.Income), c.formatAmount(p.Expenses), c.formatAmount(p.Net), rate}
+	}
+
+	c.printTable(table{
+		headers:   []string{"Period", "Income", "Expenses", "Net", "Savings rate"},
+		minWidths: []int{12, 8, 8, 8},
+		rows:      rows,
+	})
+	return nil
+}
+
+// accumulate adapts the Add method of an accumulator to a scan callback
+func accumulate(add func(expense.Expense)) func(expense.Expense) error {
+	return func(e expense.Expense) error {
+		add(e)
+		return nil
+	}
+}
+
 // summaryCommand creates the summary command
 func (c *commands) summaryCommand() *cobra.Command {
 	summaryCmd := &cobra.Command{
-		Use:   "summary",
-		Short: "Display total expenses or monthly summary",
-		Args:  cobra.NoArgs,
+		Use:     "summary",
+		Short:   "Display total expenses or monthly summary",
+		Long:    "Display total expenses or monthly summary, optionally broken down by category, account or payee,\nor the cash flow per period. Income and transfers do not count as expenses.",
+		Example: "expense-tracker summary --month 4 --by payee --top 5\nexpense-tracker summary --cashflow --period week\nexpense-tracker summary --by category --as-of 2025-03-31",
+		Args:    cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			m, _ := cmd.Flags().GetInt("month")
 			month := time.Month(m)
@@ -22,27 +131,98 @@ func (c *commands) summaryCommand() *cobra.Command {
 				return
 			}
 
-			expenses, err := c.service.ListExpenses()
+			var by expense.GroupBy
+			if group, _ := cmd.Flags().GetString("by"); group != "" {
+				parsed, err := expense.ParseGroupBy(group)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				by = parsed
+			}
+
+			output, err := c.outputFormat(cmd)
 			if err != nil {
-				fmt.Println("Error:", err)
+				fmt.Println(err)
+				return
+			}
+
+			top, _ := cmd.Flags().GetInt("top")
+			if top < 0 {
+				fmt.Println("Invalid top. Please enter a positive number.")
+				return
+			}
+
+			var filter expense.Filter
+			if m != 0 {
+				filter.From = time.Date(time.Now().Year(), month, 1, 0, 0, 0, 0, time.UTC)
+				filter.To = filter.From.AddDate(0, 1, -1)
+			}
+
+			if cashflow, _ := cmd.Flags().GetBool("cashflow"); cashflow {
+				p, _ := cmd.Flags().GetString("period")
+				period, err := expense.ParsePeriod(p)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+
+				periods := expense.NewCashflowAccumulator(period, c.weekStart())
+				if err := c.scanExpenses(cmd, filter, accumulate(periods.Add)); err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+
+				if err := c.printCashflow(periods.Periods(), period, output); err != nil {
+					fmt.Println("Error printing summary:", err)
+				}
+				return
+			}
+
+			if by != "" {
+				groups := expense.NewGroupAccumulator(by)
+				if err := c.scanExpenses(cmd, filter, accumulate(groups.Add)); err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+
+				totals := groups.Totals()
+				if top > 0 && len(totals) > top {
+					totals = totals[:top]
+				}
+
+				if err := c.printGroupTotals(totals, by, output); err != nil {
+					fmt.Println("Error printing summary:", err)
+				}
 				return
 			}
 
 			var totalExpenses int
-			for _, expense := range expenses {
-				if m == 0 || (time.Now().Year() == expense.Date.Year() && month == expense.Date.Month()) {
-					totalExpenses += expense.Amount
+			err = c.scanExpenses(cmd, filter, func(expense expense.Expense) error {
+				if expense.IsExpense() {
+					totalExpenses += expense.NetAmount()
 				}
+				return nil
+			})
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
 			}
 
 			if m == 0 {
-				fmt.Printf("Total expenses: $%d\n", totalExpenses)
+				fmt.Printf("Total expenses: %s\n", c.formatAmount(totalExpenses))
 			} else {
-				fmt.Printf("Monthly summary for %s %d: $%d\n", month.String(), time.Now().Year(), totalExpenses)
+				fmt.Printf("Monthly summary for %s %d: %s\n", month.String(), time.Now().Year(), c.formatAmount(totalExpenses))
 			}
 		},
 	}
 
 	summaryCmd.Flags().IntP("month", "m", 0, "month for summary (1-12)")
+	summaryCmd.Flags().String("by", "", "Break the summary down by category, account or payee")
+	summaryCmd.Flags().Int("top", 0, "Only show the given number of groups with the highest totals")
+	summaryCmd.Flags().Bool("cashflow", false, "Show income, expenses, net and savings rate per period")
+	summaryCmd.Flags().String("period", string(expense.PeriodMonth), "Period of the cash flow, one of week, month, year")
+	addAsOfFlag(summaryCmd)
+	addOutputFlag(summaryCmd)
 	return summaryCmd
 }
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// parseAge parses an age such as 30d, a number of days, or a duration such as 12h
+func parseAge(age string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(age, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q: must be a number of days such as 30d or a duration such as 12h", age)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q: must be a number of days such as 30d or a duration such as 12h", age)
+	}
+	return d, nil
+}
+
+// trashCommand creates the trash command and its subcommands
+func (c *commands) trashCommand() *cobra.Command {
+	trashCmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Inspect and empty the deleted expenses",
+		Long:  "Deleted expenses are kept in the trash until it is emptied, restore them with restore --id",
+		Args:  cobra.NoArgs,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the deleted expenses",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := c.outputFormat(cmd)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			trash, err := c.service.TrashedExpenses(cmd.Context())
+			if err != nil {
+				fmt.Println("Error listing trash:", err)
+				return
+			}
+
+			switch output {
+			case "json":
+				err = printJSON(trash)
+			case "csv":
+				rows := make([][]string, len(trash))
+				for i, trashed := range trash {
+					rows[i] = []string{
+						strconv.Itoa(trashed.ID),
+						strconv.Itoa(trashed.Amount),
+						trashed.Category,
+						c.formatDate(trashed.Date),
+						trashed.Description,
+						trashed.DeletedAt.Format(time.RFC3339),
+					}
+				}
+				err = printCSV([]string{"id", "amount", "category", "date", "description", "deleted_at"}, rows)
+			default:
+				if len(trash) == 0 {
+					fmt.Println("The trash is empty.")
+					return
+				}
+
+				rows := make([][]string, len(trash))
+				for i, trashed := range trash {
+					rows[i] = []string{
+						strconv.Itoa(trashed.ID),
+						c.formatAmount(trashed.NetAmount()),
+						trashed.Category,
+						c.formatDate(trashed.Date),
+						trashed.Description,
+						trashed.DeletedAt.Local().Format(time.DateTime),
+					}
+				}
+				c.printTable(table{
+					headers:   []string{"ID", "Amount", "Category", "Date", "Description", "Deleted"},
+					minWidths: []int{4, 8, 12, 12, 25},
+					rows:      rows,
+				})
+			}
+			if err != nil {
+				fmt.Println("Error printing trash:", err)
+			}
+		},
+	}
+	addOutputFlag(listCmd)
+	trashCmd.AddCommand(listCmd)
+
+	emptyCmd := &cobra.Command{
+		Use:     "empty",
+		Short:   "Permanently remove deleted expenses",
+		Example: "expense-tracker trash empty --older-than 30d",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			age, _ := cmd.Flags().GetString("older-than")
+			olderThan, err := parseAge(age)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			removed, err := c.service.EmptyTrash(cmd.Context(), olderThan)
+			if err != nil {
+				fmt.Println("Error emptying trash:", err)
+				return
+			}
+
+			fmt.Printf("Permanently removed %d deleted expenses\n", removed)
+		},
+	}
+	emptyCmd.Flags().String("older-than", "0d", "Only remove expenses deleted longer ago, e.g. 30d or 12h")
+	trashCmd.AddCommand(emptyCmd)
+
+	return trashCmd
+}
+
+// restoreDeleted moves the deleted expense with the ID from the trash back among the expenses
+func (c *commands) restoreDeleted(ctx context.Context, id int) {
+	if err := expense.ValidateID(id); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if err := c.service.RestoreExpense(ctx, id); err != nil {
+		fmt.Printf("Error restoring expense with ID %d: %v\n", id, err)
+		return
+	}
+
+	fmt.Printf("Expense with ID %d restored successfully\n", id)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// replayCommand creates the undo or redo command, replaying the mutations with replay
+func (c *commands) replayCommand(use, short, example, verb string, replay func(ctx context.Context, n int) ([]expense.JournalEntry, error)) *cobra.Command {
+	replayCmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Example: example,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, _ := cmd.Flags().GetInt("steps")
+			if n <= 0 {
+				fmt.Println("Invalid steps. Please enter a positive number.")
+				return
+			}
+
+			entries, err := replay(cmd.Context(), n)
+			for _, entry := range entries {
+				fmt.Printf("%s the %s\n", verb, entry.Describe())
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	replayCmd.Flags().IntP("steps", "n", 1, "Number of changes")
+
+	return replayCmd
+}
+
+// undoCommand creates the undo command
+func (c *commands) undoCommand() *cobra.Command {
+	return c.replayCommand(
+		"undo",
+		"Revert the last changes to the expenses",
+		"expense-tracker undo\nexpense-tracker undo -n 3",
+		"Undid",
+		// The service is only set once the command runs, so it is looked up then
+		func(ctx context.Context, n int) ([]expense.JournalEntry, error) { return c.service.Undo(ctx, n) },
+	)
+}
+
+// redoCommand creates the redo command
+func (c *commands) redoCommand() *cobra.Command {
+	return c.replayCommand(
+		"redo",
+		"Apply undone changes to the expenses again",
+		"expense-tracker redo\nexpense-tracker redo -n 3",
+		"Redid",
+		// The service is only set once the command runs, so it is looked up then
+		func(ctx context.Context, n int) ([]expense.JournalEntry, error) { return c.service.Redo(ctx, n) },
+	)
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configuration values,
+// e.g. EXPENSE_TRACKER_CURRENCY overrides the currency key
+const EnvPrefix = "EXPENSE_TRACKER_"
+
+var ErrUnknownKey = errors.New("unknown configuration key")
+
+// Config holds the user preferences
+type Config struct {
+	Profile    string `yaml:"profile,omitempty"`     // Active profile, only read from the global configuration file
+	Storage    string `yaml:"storage,omitempty"`     // Storage backend
+	DataDir    string `yaml:"data_dir,omitempty"`    // Directory to store the data in
+	Currency   string `yaml:"currency,omitempty"`    // ISO 4217 code of the currency amounts are displayed in
+	DateFormat string `yaml:"date_format,omitempty"` // Go time layout dates are displayed in
+	WeekStart  string `yaml:"week_start,omitempty"`  // First day of the week, monday or sunday
+	TableStyle string `yaml:"table_style,omitempty"` // Border style of tables, rounded, ascii or plain
+	Output     string `yaml:"output,omitempty"`      // Default output format, table, json or csv
+}
+
+// Default returns the configuration used when no value is set
+func Default() *Config {
+	return &Config{
+		Profile:    DefaultProfile,
+		Storage:    "fs",
+		Currency:   "USD",
+		DateFormat: time.DateOnly,
+		WeekStart:  "monday",
+		TableStyle: "rounded",
+		Output:     "table",
+	}
+}
+
+// key describes a configuration key and how to access it
+type key struct {
+	name   string
+	field  func(c *Config) *string
+	values []string // Allowed values, any value is allowed if empty
+}
+
+var keys = []key{
+	{name: "profile", field: func(c *Config) *string { return &c.Profile }},
+	{name: "storage", field: func(c *Config) *string { return &c.Storage }, values: []string{"fs", "events"}},
+	{name: "data_dir", field: func(c *Config) *string { return &c.DataDir }},
+	{name: "currency", field: func(c *Config) *string { return &c.Currency }},
+	{name: "date_format", field: func(c *Config) *string { return &c.DateFormat }},
+	{name: "week_start", field: func(c *Config) *string { return &c.WeekStart }, values: []string{"monday", "sunday"}},
+	{name: "table_style", field: func(c *Config) *string { return &c.TableStyle }, values: []string{"rounded", "ascii", "plain"}},
+	{name: "output", field: func(c *Config) *string { return &c.Output }, values: OutputFormats},
+}
+
+// LocalKeys lists the keys describing the installation rather than preferences,
+// which are not carried over to another installation, e.g. by restoring a backup
+var LocalKeys = []string{"profile", "storage", "data_dir"}
+
+// OutputFormats lists the supported output formats
+var OutputFormats = []string{"table", "json", "csv"}
+
+func lookup(name string) (key, error) {
+	for _, k := range keys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return key{}, fmt.Errorf("%w: %s", ErrUnknownKey, name)
+}
+
+// Keys returns the names of all configuration keys
+func Keys() []string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.name
+	}
+	return names
+}
+
+// Get returns the value of the key
+func (c *Config) Get(name string) (string, error) {
+	k, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return *k.field(c), nil
+}
+
+// Set validates and sets the value of the key
+func (c *Config) Set(name, value string) error {
+	k, err := lookup(name)
+	if err != nil {
+		return err
+	}
+
+	value = strings.TrimSpace(value)
+	if err := k.validate(value); err != nil {
+		return err
+	}
+
+	*k.field(c) = value
+	return nil
+}
+
+// validate checks whether the value is allowed for the key, an empty value unsets the key
+func (k key) validate(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if len(k.values) > 0 && !slices.Contains(k.values, value) {
+		return fmt.Errorf("invalid %s: must be one of %s", k.name, strings.Join(k.values, ", "))
+	}
+
+	switch k.name {
+	case "profile":
+		return ValidateProfileName(value)
+	case "currency":
+		if len(value) != 3 || strings.ToUpper(value) != value {
+			return errors.New("invalid currency: must be a three letter ISO 4217 code such as USD")
+		}
+	case "date_format":
+		return validateDateFormat(value)
+	}
+
+	return nil
+}
+
+// validateDateFormat checks that the layout writes the whole date, so the dates it formats can be read back
+func validateDateFormat(layout string) error {
+	sample := time.Date(2025, time.November, 23, 0, 0, 0, 0, time.UTC)
+	parsed, err := time.Parse(layout, sample.Format(layout))
+	if err != nil || parsed.Year() != sample.Year() || parsed.YearDay() != sample.YearDay() {
+		return fmt.Errorf("invalid date_format %q: must be a Go time layout with the year, month and day such as %s", layout, time.DateOnly)
+	}
+	return nil
+}
+
+// Values returns the non-empty values keyed by their key names
+func (c *Config) Values() map[string]string {
+	values := make(map[string]string)
+	for _, k := range keys {
+		if value := *k.field(c); value != "" {
+			values[k.name] = value
+		}
+	}
+	return values
+}
+
+// merge sets the non-empty values of other on c
+func (c *Config) merge(other *Config) {
+	for _, k := range keys {
+		if value := *k.field(other); value != "" {
+			*k.field(c) = value
+		}
+	}
+}
+
+// ApplyEnv overrides the configuration with the environment variables set through getenv
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	for _, k := range keys {
+		if value := getenv(EnvPrefix + strings.ToUpper(k.name)); value != "" {
+			if err := c.Set(k.name, value); err != nil {
+				return fmt.Errorf("%s%s: %w", EnvPrefix, strings.ToUpper(k.name), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Dir returns the configuration directory following the XDG base directory specification,
+// $XDG_CONFIG_HOME/expense-tracker falling back to ~/.config/expense-tracker
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "expense-tracker"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "expense-tracker"), nil
+}
+
+// Path returns the path of the configuration file, which can be overridden by EXPENSE_TRACKER_CONFIG
+func Path() (string, error) {
+	if path := os.Getenv(EnvPrefix + "CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// ReadFile reads the values set in the configuration file and validates them.
+// Unset keys are left empty, a missing file results in an empty configuration.
+func ReadFile(path string) (*Config, error) {
+	c, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		if err := k.validate(*k.field(c)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return c, nil
+}
+
+// ParseFile reads the values set in the configuration file like [ReadFile] without validating them,
+// so a file holding an invalid value can be read to fix it
+func ParseFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// WriteFile writes the configuration to the file, creating its directory if needed
+func WriteFile(path string, c *Config) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Load returns the configuration of the active profile.
+// The active profile is selected by EXPENSE_TRACKER_PROFILE, falling back to the global configuration file.
+func Load(path string) (*Config, error) {
+	return LoadProfile(path, "")
+}
+
+// LoadProfile returns the defaults overridden by the global configuration file, the configuration
+// file of the profile and then the environment, which always wins. An empty profile selects the active profile.
+// Profiles other than the default one keep their ledgers apart, so they do not inherit the global data_dir.
+func LoadProfile(path, profile string) (*Config, error) {
+	global, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile == "" {
+		profile = os.Getenv(EnvPrefix + "PROFILE")
+	}
+	if profile == "" {
+		profile = global.Profile
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return nil, err
+	}
+
+	c := Default()
+	c.merge(global)
+
+	if profile != DefaultProfile {
+		if !ProfileExists(path, profile) {
+			return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+		}
+
+		file, err := ReadFile(ProfilePath(path, profile))
+		if err != nil {
+			return nil, err
+		}
+		// The active profile can only be chosen globally
+		file.Profile = ""
+		c.DataDir = ""
+		c.merge(file)
+	}
+
+	if err := c.ApplyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+
+	c.Profile = profile
+	return c, nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Set(t *testing.T) {
+	t.Run("fails with unknown key", func(t *testing.T) {
+		assert.ErrorIs(t, Default().Set("unknown", "value"), ErrUnknownKey)
+	})
+	t.Run("fails with value not allowed", func(t *testing.T) {
+		assert.Error(t, Default().Set("table_style", "fancy"))
+	})
+	t.Run("fails with invalid currency", func(t *testing.T) {
+		assert.Error(t, Default().Set("currency", "euro"))
+	})
+	t.Run("fails with invalid date format", func(t *testing.T) {
+		for _, layout := range []string{"%%%", "%Y-%m-%d", "15:04", "Jan 2006"} {
+			assert.Error(t, Default().Set("date_format", layout), layout)
+		}
+	})
+	t.Run("sets date format", func(t *testing.T) {
+		for _, layout := range []string{"2006-01-02", "02/01/2006", "Jan 2, 2006", "2 January 06"} {
+			assert.NoError(t, Default().Set("date_format", layout), layout)
+		}
+	})
+	t.Run("sets trimmed value", func(t *testing.T) {
+		c := Default()
+		require.NoError(t, c.Set("currency", " EUR "))
+		assert.Equal(t, "EUR", c.Currency)
+	})
+}
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"EXPENSE_TRACKER_OUTPUT":   "json",
+		"EXPENSE_TRACKER_DATA_DIR": "/tmp/expenses",
+	}
+
+	c := Default()
+	require.NoError(t, c.ApplyEnv(func(key string) string { return env[key] }))
+	assert.Equal(t, "json", c.Output)
+	assert.Equal(t, "/tmp/expenses", c.DataDir)
+	assert.Equal(t, "USD", c.Currency)
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults without file", func(t *testing.T) {
+		c, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, Default(), c)
+	})
+
+	t.Run("file overrides defaults", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+		require.NoError(t, WriteFile(path, &Config{Currency: "EUR", WeekStart: "sunday"}))
+
+		c, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", c.Currency)
+		assert.Equal(t, "sunday", c.WeekStart)
+		assert.Equal(t, "table", c.Output)
+	})
+
+	t.Run("environment overrides file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, WriteFile(path, &Config{Currency: "EUR"}))
+		t.Setenv("EXPENSE_TRACKER_CURRENCY", "GBP")
+
+		c, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "GBP", c.Currency)
+	})
+
+	t.Run("invalid value names the key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("date_format: \"%%%\"\n"), 0644))
+
+		_, err := Load(path)
+		assert.ErrorContains(t, err, "invalid date_format")
+
+		c, err := ParseFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "%%%", c.DateFormat)
+	})
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultProfile is the profile whose configuration is the global configuration file
+const DefaultProfile = "default"
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrProfileExists   = errors.New("profile already exists")
+
+	profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+)
+
+// ValidateProfileName validates the name of a profile
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return errors.New("invalid profile name: must be 1 to 32 lowercase letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// profilesDir returns the directory holding the configuration files of the profiles
+func profilesDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "profiles")
+}
+
+// ProfilePath returns the path of the configuration file of the profile,
+// path being the global configuration file
+func ProfilePath(path, profile string) string {
+	if profile == DefaultProfile {
+		return path
+	}
+	return filepath.Join(profilesDir(path), profile+".yaml")
+}
+
+// ProfileExists reports whether the profile exists
+func ProfileExists(path, profile string) bool {
+	if profile == DefaultProfile {
+		return true
+	}
+	_, err := os.Stat(ProfilePath(path, profile))
+	return err == nil
+}
+
+// Profiles returns the names of all profiles sorted, including the default profile
+func Profiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(profilesDir(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	profiles := []string{DefaultProfile}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if ok && !entry.IsDir() && ValidateProfileName(name) == nil && name != DefaultProfile {
+			profiles = append(profiles, name)
+		}
+	}
+
+	slices.Sort(profiles[1:])
+	return profiles, nil
+}
+
+// CreateProfile creates the profile with an empty configuration file
+func CreateProfile(path, profile string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+	if ProfileExists(path, profile) {
+		return fmt.Errorf("%w: %s", ErrProfileExists, profile)
+	}
+
+	return WriteFile(ProfilePath(path, profile), &Config{})
+}
+
+// DeleteProfile deletes the configuration file of the profile, the default profile cannot be deleted
+func DeleteProfile(path, profile string) error {
+	if profile == DefaultProfile {
+		return errors.New("the default profile cannot be deleted")
+	}
+
+	if err := os.Remove(ProfilePath(path, profile)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+		}
+		return err
+	}
+	return nil
+}
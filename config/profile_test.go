@@ -0,0 +1,114 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	t.Run("fails with empty name", func(t *testing.T) {
+		assert.Error(t, ValidateProfileName(""))
+	})
+	t.Run("fails with path separator", func(t *testing.T) {
+		assert.Error(t, ValidateProfileName("../business"))
+	})
+	t.Run("fails with uppercase letters", func(t *testing.T) {
+		assert.Error(t, ValidateProfileName("Business"))
+	})
+	t.Run("validates lowercase name", func(t *testing.T) {
+		assert.NoError(t, ValidateProfileName("business-2"))
+	})
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, CreateProfile(path, "personal"))
+	require.NoError(t, CreateProfile(path, "business"))
+	assert.ErrorIs(t, CreateProfile(path, "business"), ErrProfileExists)
+
+	profiles, err := Profiles(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "business", "personal"}, profiles)
+
+	require.NoError(t, DeleteProfile(path, "personal"))
+	assert.ErrorIs(t, DeleteProfile(path, "personal"), ErrProfileNotFound)
+	assert.Error(t, DeleteProfile(path, DefaultProfile))
+
+	profiles, err = Profiles(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "business"}, profiles)
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, WriteFile(path, &Config{Currency: "EUR", Output: "csv", Profile: "business"}))
+	require.NoError(t, CreateProfile(path, "business"))
+	require.NoError(t, WriteFile(ProfilePath(path, "business"), &Config{Currency: "GBP"}))
+
+	t.Run("profile from global file", func(t *testing.T) {
+		c, err := LoadProfile(path, "")
+		require.NoError(t, err)
+		assert.Equal(t, "business", c.Profile)
+		assert.Equal(t, "GBP", c.Currency)
+		assert.Equal(t, "csv", c.Output)
+	})
+
+	t.Run("profile from environment", func(t *testing.T) {
+		t.Setenv("EXPENSE_TRACKER_PROFILE", "default")
+
+		c, err := LoadProfile(path, "")
+		require.NoError(t, err)
+		assert.Equal(t, "default", c.Profile)
+		assert.Equal(t, "EUR", c.Currency)
+	})
+
+	t.Run("explicit profile", func(t *testing.T) {
+		t.Setenv("EXPENSE_TRACKER_PROFILE", "default")
+
+		c, err := LoadProfile(path, "business")
+		require.NoError(t, err)
+		assert.Equal(t, "business", c.Profile)
+	})
+
+	t.Run("fails with missing profile", func(t *testing.T) {
+		_, err := LoadProfile(path, "missing")
+		assert.ErrorIs(t, err, ErrProfileNotFound)
+	})
+
+	t.Run("data directory is not inherited", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, WriteFile(path, &Config{DataDir: "/shared"}))
+		require.NoError(t, CreateProfile(path, "work"))
+		require.NoError(t, CreateProfile(path, "travel"))
+		require.NoError(t, WriteFile(ProfilePath(path, "travel"), &Config{DataDir: "/travel"}))
+
+		c, err := LoadProfile(path, "work")
+		require.NoError(t, err)
+		assert.Empty(t, c.DataDir)
+
+		c, err = LoadProfile(path, "travel")
+		require.NoError(t, err)
+		assert.Equal(t, "/travel", c.DataDir)
+
+		c, err = LoadProfile(path, DefaultProfile)
+		require.NoError(t, err)
+		assert.Equal(t, "/shared", c.DataDir)
+	})
+
+	t.Run("environment always wins", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, CreateProfile(path, "travel"))
+		require.NoError(t, WriteFile(ProfilePath(path, "travel"), &Config{DataDir: "/travel"}))
+		t.Setenv("EXPENSE_TRACKER_DATA_DIR", "/env")
+
+		for _, profile := range []string{"travel", DefaultProfile} {
+			c, err := LoadProfile(path, profile)
+			require.NoError(t, err)
+			assert.Equal(t, "/env", c.DataDir, profile)
+		}
+	})
+}
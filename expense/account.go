@@ -0,0 +1,159 @@
+package expense
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// AccountType is the kind of payment method of an account
+type AccountType string
+
+const (
+	AccountCredit AccountType = "credit" // Credit card
+	AccountDebit  AccountType = "debit"  // Debit card or bank account
+	AccountCash   AccountType = "cash"   // Cash
+	AccountShared AccountType = "shared" // Account shared with others
+)
+
+// AccountTypes lists all account types
+var AccountTypes = []AccountType{AccountCredit, AccountDebit, AccountCash, AccountShared}
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountExists   = errors.New("account already exists")
+)
+
+// Account is a payment method or funding account expenses are paid with
+type Account struct {
+	Name string      `json:"name"` // Unique name of the account, compared case-insensitively
+	Type AccountType `json:"type"` // Kind of payment method
+}
+
+// ParseAccountName parses an account name and returns an error if it's invalid
+func ParseAccountName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if runeCount := utf8.RuneCountInString(name); runeCount == 0 {
+		return "", errors.New("invalid account name: must not be empty")
+	} else if runeCount > 50 {
+		return "", errors.New("invalid account name: must not exceed 50 characters")
+	}
+
+	return name, nil
+}
+
+// ParseAccountType parses an account type and returns an error if it's invalid
+func ParseAccountType(accountType string) (AccountType, error) {
+	t := AccountType(strings.ToLower(strings.TrimSpace(accountType)))
+	if !slices.Contains(AccountTypes, t) {
+		return "", fmt.Errorf("invalid account type %q: must be one of credit, debit, cash, shared", accountType)
+	}
+	return t, nil
+}
+
+// AccountRegistry stores the accounts in a CSV file in the data directory
+type AccountRegistry struct {
+	file string
+}
+
+// NewAccountRegistry creates a new AccountRegistry storing the accounts in dirname
+func NewAccountRegistry(dirname string) *AccountRegistry {
+	return &AccountRegistry{file: filepath.Join(dirname, "accounts.txt")}
+}
+
+// List lists all accounts in the order they were added
+func (r *AccountRegistry) List() ([]Account, error) {
+	file, err := os.Open(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Account{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]Account, len(records))
+	for i, record := range records {
+		if len(record) != 2 {
+			return nil, errors.New("unexpected account record length")
+		}
+		accounts[i] = Account{Name: record[0], Type: AccountType(record[1])}
+	}
+
+	return accounts, nil
+}
+
+// Find returns the account with the name, compared case-insensitively
+func (r *AccountRegistry) Find(name string) (*Account, error) {
+	accounts, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		if strings.EqualFold(account.Name, name) {
+			return &account, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+}
+
+// Add adds a new account, failing if an account with the same name exists
+func (r *AccountRegistry) Add(account Account) error {
+	if _, err := r.Find(account.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrAccountExists, account.Name)
+	} else if !errors.Is(err, ErrAccountNotFound) {
+		return err
+	}
+
+	file, err := os.OpenFile(r.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0655)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll([][]string{{account.Name, string(account.Type)}})
+}
+
+// Delete deletes the account with the name, expenses paid with it keep referring to it by name
+func (r *AccountRegistry) Delete(name string) error {
+	accounts, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(accounts), func(account Account) bool { return strings.EqualFold(account.Name, name) })
+	if len(kept) == len(accounts) {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+
+	return r.Replace(kept)
+}
+
+// Replace replaces all stored accounts
+func (r *AccountRegistry) Replace(accounts []Account) error {
+	records := make([][]string, len(accounts))
+	for i, account := range accounts {
+		records[i] = []string{account.Name, string(account.Type)}
+	}
+
+	file, err := os.Create(r.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll(records)
+}
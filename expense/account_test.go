@@ -0,0 +1,43 @@
+package expense
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAccountType(t *testing.T) {
+	t.Run("fails with unknown type", func(t *testing.T) {
+		_, err := ParseAccountType("crypto")
+		assert.Error(t, err)
+	})
+	t.Run("parses case-insensitively", func(t *testing.T) {
+		accountType, err := ParseAccountType(" Credit ")
+		require.NoError(t, err)
+		assert.Equal(t, AccountCredit, accountType)
+	})
+}
+
+func TestAccountRegistry(t *testing.T) {
+	r := NewAccountRegistry(t.TempDir())
+
+	accounts, err := r.List()
+	require.NoError(t, err)
+	assert.Empty(t, accounts)
+
+	require.NoError(t, r.Add(Account{Name: "Amex", Type: AccountCredit}))
+	require.NoError(t, r.Add(Account{Name: "Wallet", Type: AccountCash}))
+	assert.ErrorIs(t, r.Add(Account{Name: "amex", Type: AccountDebit}), ErrAccountExists)
+
+	account, err := r.Find("AMEX")
+	require.NoError(t, err)
+	assert.Equal(t, Account{Name: "Amex", Type: AccountCredit}, *account)
+
+	require.NoError(t, r.Delete("amex"))
+	assert.ErrorIs(t, r.Delete("amex"), ErrAccountNotFound)
+
+	accounts, err = r.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Account{{Name: "Wallet", Type: AccountCash}}, accounts)
+}
@@ -0,0 +1,255 @@
+package expense
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrAuditChainBroken is returned when the audit log was modified after it was written
+var ErrAuditChainBroken = errors.New("audit log chain is broken")
+
+const (
+	OpEmptyTrash Operation = "empty_trash" // Expenses were removed from the trash permanently
+	OpRepair     Operation = "repair"      // Bad rows were quarantined by doctor --fix
+	OpMigrate    Operation = "migrate"     // The storage format was upgraded
+)
+
+// Actor identifies who made a change
+type Actor struct {
+	User    string `json:"user"`    // Name of the operating system user
+	Host    string `json:"host"`    // Hostname of the machine
+	Command string `json:"command"` // Command line the change was made with
+}
+
+// CurrentActor returns the current operating system user and hostname with the command line args
+func CurrentActor(args []string) Actor {
+	actor := Actor{Command: strings.Join(args, " ")}
+
+	if u, err := user.Current(); err == nil {
+		actor.User = u.Username
+	} else {
+		actor.User = os.Getenv("USER")
+	}
+
+	actor.Host, _ = os.Hostname()
+	return actor
+}
+
+// AuditEntry records a change of the expenses, who made it and when.
+// Each entry holds the hash of the previous one, so modifying or removing an entry breaks the chain.
+type AuditEntry struct {
+	Seq  int       `json:"seq"`  // Position of the entry in the log, starting from 1
+	Time time.Time `json:"time"` // Time of the change
+	Actor
+	Op     Operation `json:"op"`               // Kind of the change
+	Ref    int       `json:"ref,omitempty"`    // Seq of the journal entry undone or redone
+	Before []Expense `json:"before,omitempty"` // Affected expenses before the change
+	After  []Expense `json:"after,omitempty"`  // Affected expenses after the change
+	Note   string    `json:"note,omitempty"`   // Details of a change not affecting single expenses
+
+	PrevHash string `json:"prev_hash"` // Hash of the previous entry, empty for the first one
+	Hash     string `json:"hash"`      // SHA-256 of the entry without its hash, hex encoded
+}
+
+// computeHash returns the hash of the entry, which covers all fields but the hash itself
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Affects reports whether the change affected the expense with the ID
+func (e AuditEntry) Affects(id int) bool {
+	has := func(expense Expense) bool { return expense.ID == id }
+	return slices.ContainsFunc(e.Before, has) || slices.ContainsFunc(e.After, has)
+}
+
+// VerifyAudit verifies the hash chain of the entries and returns an error naming the first broken entry
+func VerifyAudit(entries []AuditEntry) error {
+	var prev string
+	for i, entry := range entries {
+		if entry.Seq != i+1 {
+			return fmt.Errorf("%w: entry %d has sequence number %d", ErrAuditChainBroken, i+1, entry.Seq)
+		}
+		if entry.PrevHash != prev {
+			return fmt.Errorf("%w: entry %d does not follow entry %d", ErrAuditChainBroken, entry.Seq, entry.Seq-1)
+		}
+		if entry.Hash != entry.computeHash() {
+			return fmt.Errorf("%w: entry %d was modified", ErrAuditChainBroken, entry.Seq)
+		}
+		prev = entry.Hash
+	}
+	return nil
+}
+
+// AuditHead is the sequence number and hash of the last entry of an audit log. It is kept apart
+// from the log, so removing the last entries or recomputing all hashes is detected.
+type AuditHead struct {
+	Seq  int
+	Hash string
+}
+
+// VerifyAuditHead verifies the hash chain of the entries as [VerifyAudit] and that the last entry is the head
+func VerifyAuditHead(entries []AuditEntry, head AuditHead) error {
+	if err := VerifyAudit(entries); err != nil {
+		return err
+	}
+
+	var last AuditHead
+	if len(entries) > 0 {
+		last = AuditHead{Seq: entries[len(entries)-1].Seq, Hash: entries[len(entries)-1].Hash}
+	}
+	switch {
+	case last.Seq < head.Seq:
+		return fmt.Errorf("%w: entries after entry %d were removed", ErrAuditChainBroken, last.Seq)
+	case last != head:
+		return fmt.Errorf("%w: entry %d is not the recorded head", ErrAuditChainBroken, last.Seq)
+	}
+	return nil
+}
+
+// AuditLog records who changed the expenses
+type AuditLog interface {
+	Record(entry JournalEntry) error // Appends the change with the actor, time and hash chain.
+	Entries() ([]AuditEntry, error)  // Lists all entries in the order they were recorded.
+}
+
+// AuditLogFS appends the audit entries as JSON lines to a file in the data directory,
+// and keeps the head of the log in a second file
+type AuditLogFS struct {
+	file     string
+	headfile string
+	actor    Actor
+}
+
+// NewAuditLogFS creates a new AuditLogFS keeping the log in dirname and recording the changes as made by actor
+func NewAuditLogFS(dirname string, actor Actor) *AuditLogFS {
+	return &AuditLogFS{
+		file:     filepath.Join(dirname, "audit.jsonl"),
+		headfile: filepath.Join(dirname, "audit-head.txt"),
+		actor:    actor,
+	}
+}
+
+// Head returns the head of the log read from the head file, and false if the file is missing
+func (l *AuditLogFS) Head() (AuditHead, bool, error) {
+	data, err := os.ReadFile(l.headfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return AuditHead{}, false, nil
+		}
+		return AuditHead{}, false, err
+	}
+
+	seq, hash, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	n, err := strconv.Atoi(seq)
+	if err != nil {
+		return AuditHead{}, false, fmt.Errorf("invalid audit head %q", data)
+	}
+	return AuditHead{Seq: n, Hash: hash}, true, nil
+}
+
+// Verify verifies the entries against the head and returns how many entries there are.
+// A log with entries but no head fails, as a removed head file cannot be told apart from a log
+// written before the head was kept. Such a log gets a head with its next change.
+func (l *AuditLogFS) Verify() (int, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return 0, err
+	}
+	head, ok, err := l.Head()
+	if err != nil {
+		return 0, err
+	}
+	if !ok && len(entries) > 0 {
+		return 0, fmt.Errorf("%w: the head file %s is missing, logs written before it was kept get one with their next change", ErrAuditChainBroken, filepath.Base(l.headfile))
+	}
+	return len(entries), VerifyAuditHead(entries, head)
+}
+
+// Entries lists all entries, a missing file results in no entries
+func (l *AuditLogFS) Entries() ([]AuditEntry, error) {
+	file, err := os.Open(l.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AuditEntry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	// Entries of replacements hold all expenses and may be long
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit entry on line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Record appends the change as a single line chained to the head, which is then moved to it.
+// The log is only read when it has no head yet.
+func (l *AuditLogFS) Record(change JournalEntry) error {
+	head, ok, err := l.Head()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		entries, err := l.Entries()
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			head = AuditHead{Seq: entries[len(entries)-1].Seq, Hash: entries[len(entries)-1].Hash}
+		}
+	}
+
+	entry := AuditEntry{
+		Seq:      head.Seq + 1,
+		Time:     time.Now(),
+		Actor:    l.actor,
+		Op:       change.Op,
+		Ref:      change.Ref,
+		Before:   change.Before,
+		After:    change.After,
+		Note:     change.Note,
+		PrevHash: head.Hash,
+	}
+	entry.Hash = entry.computeHash()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return os.WriteFile(l.headfile, []byte(fmt.Sprintf("%d %s", entry.Seq, entry.Hash)), 0644)
+}
+
+var _ AuditLog = (*AuditLogFS)(nil)
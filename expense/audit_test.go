@@ -0,0 +1,108 @@
+package expense
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogFS(t *testing.T) {
+	dir := t.TempDir()
+	actor := Actor{User: "alice", Host: "laptop", Command: "expense-tracker add"}
+	log := NewAuditLogFS(dir, actor)
+
+	entries, err := log.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	added := Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch"}
+	edited := added
+	edited.Amount = 12
+	require.NoError(t, log.Record(JournalEntry{Op: OpAdd, After: []Expense{added}}))
+	require.NoError(t, log.Record(JournalEntry{Op: OpEdit, Before: []Expense{added}, After: []Expense{edited}}))
+	require.NoError(t, log.Record(JournalEntry{Op: OpUndo, Ref: 2, Before: []Expense{edited}, After: []Expense{added}}))
+
+	entries, err = log.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, actor, entries[0].Actor)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, 2, entries[2].Ref)
+	assert.True(t, entries[2].Affects(1))
+	assert.False(t, entries[2].Affects(2))
+	require.NoError(t, VerifyAudit(entries))
+
+	t.Run("modified entry", func(t *testing.T) {
+		tampered := append([]AuditEntry(nil), entries...)
+		tampered[1].After = []Expense{added}
+		assert.ErrorIs(t, VerifyAudit(tampered), ErrAuditChainBroken)
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyAudit([]AuditEntry{entries[0], entries[2]}), ErrAuditChainBroken)
+	})
+
+	t.Run("modified file", func(t *testing.T) {
+		file := filepath.Join(dir, "audit.jsonl")
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(file, []byte(strings.Replace(string(data), `"user":"alice"`, `"user":"bob"`, 1)), 0644))
+
+		entries, err := log.Entries()
+		require.NoError(t, err)
+		assert.ErrorIs(t, VerifyAudit(entries), ErrAuditChainBroken)
+	})
+}
+
+func TestAuditLogFS_Head(t *testing.T) {
+	dir := t.TempDir()
+	log := NewAuditLogFS(dir, Actor{User: "alice"})
+	for _, op := range []Operation{OpAdd, OpEdit, OpDelete} {
+		require.NoError(t, log.Record(JournalEntry{Op: op, After: []Expense{{ID: 1, Amount: 10}}}))
+	}
+	n, err := log.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	entries, err := log.Entries()
+	require.NoError(t, err)
+	head, ok, err := log.Head()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, AuditHead{Seq: 3, Hash: entries[2].Hash}, head)
+
+	t.Run("truncated log", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyAuditHead(entries[:2], head), ErrAuditChainBroken)
+		assert.ErrorIs(t, VerifyAuditHead(nil, head), ErrAuditChainBroken)
+	})
+
+	t.Run("recomputed hashes", func(t *testing.T) {
+		rewritten := append([]AuditEntry(nil), entries...)
+		rewritten[0].User = "bob"
+		for i := range rewritten {
+			if i > 0 {
+				rewritten[i].PrevHash = rewritten[i-1].Hash
+			}
+			rewritten[i].Hash = rewritten[i].computeHash()
+		}
+		require.NoError(t, VerifyAudit(rewritten), "the chain alone is consistent")
+		assert.ErrorIs(t, VerifyAuditHead(rewritten, head), ErrAuditChainBroken)
+	})
+
+	t.Run("missing head", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dir, "audit-head.txt")))
+		_, err := log.Verify()
+		assert.ErrorIs(t, err, ErrAuditChainBroken)
+
+		// A log written before the head was kept continues its chain and gets one
+		require.NoError(t, log.Record(JournalEntry{Op: OpUndo, Ref: 3}))
+		n, err := log.Verify()
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+	})
+}
@@ -0,0 +1,127 @@
+package expense
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// ArchiveFormat identifies a backup archive produced by this application.
+	ArchiveFormat = "expense-tracker-backup"
+	// ArchiveVersion is the version of the archive layout written by [WriteArchive].
+	ArchiveVersion = 3
+)
+
+var (
+	ErrArchiveFormat   = errors.New("not an expense tracker backup")
+	ErrArchiveVersion  = errors.New("unsupported backup version")
+	ErrArchiveChecksum = errors.New("backup checksum mismatch")
+)
+
+// Archive is a full backup of the expense data.
+type Archive struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	LastID    int       `json:"last_id"`  // ID counter at the time of the backup
+	Expenses  []Expense `json:"expenses"` // All expenses at the time of the backup
+
+	// Settings holds the configuration values keyed by their names, added in version 2
+	Settings map[string]string `json:"settings,omitempty"`
+
+	// Added in version 3, the remaining data kept next to the expenses
+	Trash       []TrashedExpense `json:"trash,omitempty"`       // Deleted expenses in the trash
+	Journal     []JournalEntry   `json:"journal,omitempty"`     // History of the changes undo and redo replay
+	Accounts    []Account        `json:"accounts,omitempty"`    // Registered payment accounts
+	Payees      []Payee          `json:"payees,omitempty"`      // Registered payees with their patterns
+	People      []string         `json:"people,omitempty"`      // People expenses are shared with
+	Settlements []Settlement     `json:"settlements,omitempty"` // Recorded settlements between people
+	Rules       string           `json:"rules,omitempty"`       // Categorization rules file as written, with its comments
+
+	// Checksum is the hex encoded SHA-256 of the archive with an empty checksum.
+	Checksum string `json:"checksum"`
+}
+
+// RestoreMode controls how an archive is applied to the current expenses.
+type RestoreMode int
+
+const (
+	// RestoreReplace discards the current expenses and replaces them with the archived ones.
+	RestoreReplace RestoreMode = iota
+	// RestoreMerge keeps the current expenses and adds the archived ones which are not present.
+	RestoreMerge
+)
+
+// checksum computes the checksum of the archive ignoring its current checksum.
+func (a Archive) checksum() (string, error) {
+	a.Checksum = ""
+	data, err := json.Marshal(a)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Validate checks the format, version and checksum of the archive.
+func (a *Archive) Validate() error {
+	if a.Format != ArchiveFormat {
+		return ErrArchiveFormat
+	}
+
+	if a.Version < 1 || a.Version > ArchiveVersion {
+		return fmt.Errorf("%w: %d", ErrArchiveVersion, a.Version)
+	}
+
+	sum, err := a.checksum()
+	if err != nil {
+		return err
+	}
+	if sum != a.Checksum {
+		return ErrArchiveChecksum
+	}
+
+	for _, expense := range a.Expenses {
+		if err := ValidateID(expense.ID); err != nil {
+			return err
+		}
+		if expense.ID > a.LastID {
+			return fmt.Errorf("invalid backup: expense id %d exceeds last id %d", expense.ID, a.LastID)
+		}
+	}
+
+	return nil
+}
+
+// WriteArchive computes the checksum of the archive and writes it to w as JSON.
+func WriteArchive(w io.Writer, a *Archive) error {
+	sum, err := a.checksum()
+	if err != nil {
+		return err
+	}
+	a.Checksum = sum
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
+
+// ReadArchive reads an archive from r and validates it.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArchiveFormat, err)
+	}
+
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
@@ -0,0 +1,61 @@
+package expense
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	newArchive := func() *Archive {
+		return &Archive{
+			Format:    ArchiveFormat,
+			Version:   ArchiveVersion,
+			CreatedAt: time.Date(2025, time.May, 1, 12, 0, 0, 0, time.UTC),
+			LastID:    2,
+			Expenses: []Expense{
+				{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)},
+				{ID: 2, Amount: 20, Category: "Food", Description: "Dinner", Date: time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC)},
+			},
+		}
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteArchive(&buf, newArchive()))
+
+		archive, err := ReadArchive(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, 2, archive.LastID)
+		assert.Equal(t, newArchive().Expenses, archive.Expenses)
+	})
+
+	t.Run("fails on tampered content", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteArchive(&buf, newArchive()))
+
+		tampered := strings.Replace(buf.String(), `"amount": 10`, `"amount": 11`, 1)
+		_, err := ReadArchive(strings.NewReader(tampered))
+		assert.ErrorIs(t, err, ErrArchiveChecksum)
+	})
+
+	t.Run("fails on unknown format", func(t *testing.T) {
+		_, err := ReadArchive(strings.NewReader(`{"format":"other","version":1}`))
+		assert.ErrorIs(t, err, ErrArchiveFormat)
+	})
+
+	t.Run("fails on newer version", func(t *testing.T) {
+		archive := newArchive()
+		archive.Version = ArchiveVersion + 1
+
+		var buf bytes.Buffer
+		require.NoError(t, WriteArchive(&buf, archive))
+
+		_, err := ReadArchive(&buf)
+		assert.ErrorIs(t, err, ErrArchiveVersion)
+	})
+}
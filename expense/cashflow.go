@@ -0,0 +1,121 @@
+package expense
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of a transaction
+type Kind string
+
+const (
+	KindExpense  Kind = "expense"  // Money spent, the default
+	KindIncome   Kind = "income"   // Money received
+	KindTransfer Kind = "transfer" // Money moved between own accounts, neither spent nor received
+)
+
+// Kinds lists all kinds of transactions
+var Kinds = []Kind{KindExpense, KindIncome, KindTransfer}
+
+// ParseKind parses the kind of a transaction and returns an error if it's invalid
+func ParseKind(kind string) (Kind, error) {
+	k := Kind(strings.ToLower(strings.TrimSpace(kind)))
+	if !slices.Contains(Kinds, k) {
+		return "", fmt.Errorf("invalid kind %q: must be one of expense, income, transfer", kind)
+	}
+	return k, nil
+}
+
+// Period is the length of the periods a cash flow is reported by
+type Period string
+
+const (
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+	PeriodYear  Period = "year"
+)
+
+// ParsePeriod parses a period and returns an error if it's invalid
+func ParsePeriod(period string) (Period, error) {
+	p := Period(strings.ToLower(strings.TrimSpace(period)))
+	if p != PeriodWeek && p != PeriodMonth && p != PeriodYear {
+		return "", fmt.Errorf("invalid period %q: must be one of week, month, year", period)
+	}
+	return p, nil
+}
+
+// Start returns the first day of the period containing the date, weeks start on weekStart
+func (p Period) Start(date time.Time, weekStart time.Weekday) time.Time {
+	year, month, day := date.Date()
+	switch p {
+	case PeriodWeek:
+		offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, date.Location())
+	case PeriodYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, date.Location())
+	default:
+		return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	}
+}
+
+// CashflowPeriod is the money received and spent in a period
+type CashflowPeriod struct {
+	Start       time.Time `json:"start"`        // First day of the period
+	Income      int       `json:"income"`       // Total income
+	Expenses    int       `json:"expenses"`     // Total expenses less refunds, transfers excluded
+	Net         int       `json:"net"`          // Income minus expenses
+	SavingsRate float64   `json:"savings_rate"` // Share of the income not spent, 0 without income
+}
+
+// CashflowAccumulator sums income and expenses per period one at a time, holding only the totals of the periods.
+// Transfers move money between own accounts and are left out.
+type CashflowAccumulator struct {
+	period    Period
+	weekStart time.Weekday
+	periods   []CashflowPeriod
+	index     map[time.Time]int
+}
+
+// NewCashflowAccumulator creates a new CashflowAccumulator summing per period
+func NewCashflowAccumulator(period Period, weekStart time.Weekday) *CashflowAccumulator {
+	return &CashflowAccumulator{period: period, weekStart: weekStart, index: make(map[time.Time]int)}
+}
+
+// Add adds the income or expense to the totals of its period, transfers are left out
+func (c *CashflowAccumulator) Add(expense Expense) {
+	if expense.Kind == KindTransfer {
+		return
+	}
+
+	start := c.period.Start(expense.Date, c.weekStart)
+	i, ok := c.index[start]
+	if !ok {
+		i = len(c.periods)
+		c.index[start] = i
+		c.periods = append(c.periods, CashflowPeriod{Start: start})
+	}
+
+	if expense.Kind == KindIncome {
+		c.periods[i].Income += expense.Amount
+	} else {
+		c.periods[i].Expenses += expense.NetAmount()
+	}
+}
+
+// Periods returns the cash flow of each period holding any income or expenses, in chronological order
+func (c *CashflowAccumulator) Periods() []CashflowPeriod {
+	periods := slices.Clone(c.periods)
+	for i := range periods {
+		periods[i].Net = periods[i].Income - periods[i].Expenses
+		if periods[i].Income > 0 {
+			periods[i].SavingsRate = float64(periods[i].Net) / float64(periods[i].Income)
+		}
+	}
+
+	slices.SortFunc(periods, func(a, b CashflowPeriod) int {
+		return a.Start.Compare(b.Start)
+	})
+	return periods
+}
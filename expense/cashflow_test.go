@@ -0,0 +1,49 @@
+package expense
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKind(t *testing.T) {
+	kind, err := ParseKind(" Income ")
+	require.NoError(t, err)
+	assert.Equal(t, KindIncome, kind)
+
+	_, err = ParseKind("refund")
+	assert.Error(t, err)
+}
+
+func TestPeriod_Start(t *testing.T) {
+	// Thursday
+	date := time.Date(2025, 3, 13, 15, 4, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), PeriodWeek.Start(date, time.Monday))
+	assert.Equal(t, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC), PeriodWeek.Start(date, time.Sunday))
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), PeriodMonth.Start(date, time.Monday))
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PeriodYear.Start(date, time.Monday))
+}
+
+func TestCashflowAccumulator(t *testing.T) {
+	march := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC)
+	expenses := []Expense{
+		{ID: 1, Amount: 200, Date: april},
+		{ID: 2, Amount: 1000, Date: march, Kind: KindIncome},
+		{ID: 3, Amount: 250, Date: march},
+		{ID: 4, Amount: 500, Date: march, Kind: KindTransfer},
+		{ID: 5, Amount: 50, Date: march, Kind: KindExpense},
+	}
+
+	cashflow := NewCashflowAccumulator(PeriodMonth, time.Monday)
+	for _, expense := range expenses {
+		cashflow.Add(expense)
+	}
+	assert.Equal(t, []CashflowPeriod{
+		{Start: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Income: 1000, Expenses: 300, Net: 700, SavingsRate: 0.7},
+		{Start: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Expenses: 200, Net: -200},
+	}, cashflow.Periods())
+}
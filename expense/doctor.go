@@ -0,0 +1,284 @@
+package expense
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrCheckUnsupported is returned when the storage cannot be checked for integrity problems
+var ErrCheckUnsupported = errors.New("storage cannot be checked")
+
+// IssueKind is the kind of an integrity problem of a stored expense
+type IssueKind string
+
+const (
+	IssueMalformed       IssueKind = "malformed"        // The row cannot be decoded
+	IssueDuplicateID     IssueKind = "duplicate_id"     // The ID is used by an earlier row
+	IssueIDAboveCounter  IssueKind = "id_above_counter" // The ID is above the last generated ID
+	IssueInvalidCategory IssueKind = "invalid_category" // The category is empty or too long
+)
+
+// Issue is an integrity problem of a row of the stored expenses
+type Issue struct {
+	Line    int       `json:"line"`         // Line the row starts on, starting from 1
+	Kind    IssueKind `json:"kind"`         // Kind of the problem
+	ID      int       `json:"id,omitempty"` // ID of the expense, 0 if the row is malformed
+	Message string    `json:"message"`      // Description of the problem
+}
+
+// Quarantined reports whether repairing moves the row out of the expenses.
+// An ID above the counter is repaired by raising the counter instead.
+func (i Issue) Quarantined() bool {
+	return i.Kind != IssueIDAboveCounter
+}
+
+// CheckReport lists the integrity problems of the stored expenses
+type CheckReport struct {
+	Rows   int     `json:"rows"`    // Number of rows checked
+	LastID int     `json:"last_id"` // Last generated ID
+	Issues []Issue `json:"issues"`  // Problems ordered by line
+}
+
+// RepairReport describes the repair of the stored expenses
+type RepairReport struct {
+	Quarantined int    `json:"quarantined"`       // Number of rows moved to the quarantine file
+	File        string `json:"file,omitempty"`    // Quarantine file the rows were appended to
+	LastID      int    `json:"last_id,omitempty"` // ID the counter was raised to, 0 if it was not changed
+}
+
+// CheckableStorage is implemented by storages able to check and repair the stored expenses
+type CheckableStorage interface {
+	Check(ctx context.Context) (*CheckReport, error)   // Lists the integrity problems of the stored expenses.
+	Repair(ctx context.Context) (*RepairReport, error) // Quarantines the bad rows and raises the ID counter above all IDs.
+}
+
+// LenientStorage is implemented by storages able to list the expenses despite malformed rows
+type LenientStorage interface {
+	ListLenient(ctx context.Context) ([]Expense, []Issue, error) // Lists the valid expenses and the malformed rows skipped.
+}
+
+// row is a raw row of the expenses file with the problems found in it
+type row struct {
+	line    int
+	raw     []byte
+	expense *Expense
+	issues  []Issue
+}
+
+// quarantined reports whether any of the problems of the row moves it out of the expenses
+func (r row) quarantined() bool {
+	for _, issue := range r.issues {
+		if issue.Quarantined() {
+			return true
+		}
+	}
+	return false
+}
+
+// scan reads the expenses file row by row without stopping at malformed rows.
+// It returns the format header, if any, and the rows, the malformed ones with their problem.
+func (s *StorageFS) scan(ctx context.Context) ([]byte, []row, error) {
+	data, err := os.ReadFile(s.expensesfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	// The header is kept apart, so the rows can be written back exactly as they were
+	var header []byte
+	line := 1
+	if bytes.HasPrefix(data, []byte("#")) {
+		end := bytes.IndexByte(data, '\n') + 1
+		if end == 0 {
+			end = len(data)
+		}
+		header, data = data[:end], data[end:]
+		line++
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var rows []row
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		start := reader.InputOffset()
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		r := row{line: line, raw: data[start:reader.InputOffset()]}
+		line += bytes.Count(r.raw, []byte("\n"))
+		// Blank lines before the row are skipped by the reader
+		for len(r.raw) > 0 && r.raw[0] == '\n' {
+			r.raw = r.raw[1:]
+			r.line++
+		}
+
+		if err == nil {
+			r.expense, err = decode(record)
+		}
+		if err != nil {
+			r.issues = append(r.issues, Issue{Line: r.line, Kind: IssueMalformed, Message: err.Error()})
+		}
+		rows = append(rows, r)
+	}
+
+	return header, rows, nil
+}
+
+// check adds the problems of the decoded rows: IDs used by an earlier row, IDs above the counter and invalid categories
+func check(rows []row, lastID int) {
+	seen := make(map[int]int)
+	for i := range rows {
+		r, expense := &rows[i], rows[i].expense
+		if expense == nil {
+			continue
+		}
+
+		if first, ok := seen[expense.ID]; ok {
+			r.issues = append(r.issues, Issue{Line: r.line, Kind: IssueDuplicateID, ID: expense.ID, Message: fmt.Sprintf("ID %d is already used on line %d", expense.ID, first)})
+		} else {
+			seen[expense.ID] = r.line
+		}
+		if expense.ID > lastID {
+			r.issues = append(r.issues, Issue{Line: r.line, Kind: IssueIDAboveCounter, ID: expense.ID, Message: fmt.Sprintf("ID %d is above the last generated ID %d", expense.ID, lastID)})
+		}
+		if _, err := ParseCategory(expense.Category); err != nil {
+			r.issues = append(r.issues, Issue{Line: r.line, Kind: IssueInvalidCategory, ID: expense.ID, Message: err.Error()})
+		}
+	}
+}
+
+// ListLenient lists the expenses in the file like [StorageFS.List], but skips the malformed rows
+// and returns them as problems with their line numbers instead of failing
+func (s *StorageFS) ListLenient(ctx context.Context) ([]Expense, []Issue, error) {
+	if err := s.checkFormat(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	_, rows, err := s.scan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expenses := []Expense{}
+	var skipped []Issue
+	for _, r := range rows {
+		if r.expense == nil {
+			skipped = append(skipped, r.issues...)
+		} else {
+			expenses = append(expenses, *r.expense)
+		}
+	}
+	return expenses, skipped, nil
+}
+
+// Check lists the integrity problems of the expenses file
+func (s *StorageFS) Check(ctx context.Context) (*CheckReport, error) {
+	lastID, err := s.LastID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID counter: %w", err)
+	}
+
+	_, rows, err := s.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	check(rows, lastID)
+
+	report := &CheckReport{Rows: len(rows), LastID: lastID, Issues: []Issue{}}
+	for _, r := range rows {
+		report.Issues = append(report.Issues, r.issues...)
+	}
+	return report, nil
+}
+
+// Repair appends the malformed rows, the rows reusing an ID and the rows with an invalid category
+// as they were to quarantine.txt, each one preceded by a comment naming its problems, and removes
+// them from the expenses file. The ID counter is raised to the highest ID found, so a quarantined
+// row added back after fixing it by hand cannot clash with a new expense.
+func (s *StorageFS) Repair(ctx context.Context) (*RepairReport, error) {
+	lastID, err := s.LastID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID counter: %w", err)
+	}
+
+	header, rows, err := s.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	check(rows, lastID)
+
+	report := &RepairReport{}
+	var kept, quarantine bytes.Buffer
+	kept.Write(header)
+	maxID := 0
+	for _, r := range rows {
+		if r.expense != nil {
+			maxID = max(maxID, r.expense.ID)
+		}
+		if !r.quarantined() {
+			kept.Write(withNewline(r.raw))
+			continue
+		}
+
+		report.Quarantined++
+		for _, issue := range r.issues {
+			fmt.Fprintf(&quarantine, "# %s line %d: %s: %s\n", time.Now().Format(time.RFC3339), r.line, issue.Kind, issue.Message)
+		}
+		quarantine.Write(withNewline(r.raw))
+	}
+
+	if report.Quarantined > 0 {
+		report.File = filepath.Join(filepath.Dir(s.expensesfile), "quarantine.txt")
+		file, err := os.OpenFile(report.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		// The rows are quarantined first, so a failure while rewriting the expenses cannot lose them
+		if _, err := file.Write(quarantine.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := s.writeRaw(kept.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	if maxID > lastID {
+		if err := os.WriteFile(s.idsfile, []byte(strconv.Itoa(maxID)), os.ModePerm); err != nil {
+			return nil, err
+		}
+		report.LastID = maxID
+	}
+
+	return report, nil
+}
+
+// withNewline terminates the raw row with a newline, which the last row of a file may lack
+func withNewline(raw []byte) []byte {
+	if len(raw) > 0 && raw[len(raw)-1] != '\n' {
+		return append(raw[:len(raw):len(raw)], '\n')
+	}
+	return raw
+}
+
+var (
+	_ CheckableStorage = (*StorageFS)(nil)
+	_ LenientStorage   = (*StorageFS)(nil)
+)
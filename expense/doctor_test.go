@@ -0,0 +1,109 @@
+package expense
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageFS_CheckRepair(t *testing.T) {
+	dir := t.TempDir()
+	content := "#expense-tracker format 2\n" +
+		"1,10,Food,Lunch,2025-04-20\n" +
+		"2,20,Food,Dinner,2025-13-21\n" +
+		"\n" +
+		"3,5,,Taxi,2025-04-22\n" +
+		"1,7,Food,Again,2025-04-23\n" +
+		"4,8,Fun,Movie,2025-04-24"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(content), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ids.txt"), []byte("2\n"), 0644))
+	s := NewStorageFS(dir)
+
+	report, err := s.Check(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+	assert.Equal(t, 2, report.LastID)
+
+	type found struct {
+		line int
+		kind IssueKind
+	}
+	var issues []found
+	for _, issue := range report.Issues {
+		issues = append(issues, found{issue.Line, issue.Kind})
+	}
+	assert.Equal(t, []found{
+		{3, IssueMalformed},
+		{5, IssueIDAboveCounter},
+		{5, IssueInvalidCategory},
+		{6, IssueDuplicateID},
+		{7, IssueIDAboveCounter},
+	}, issues)
+
+	repair, err := s.Repair(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 3, repair.Quarantined)
+	assert.Equal(t, 4, repair.LastID)
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 4}, ids(expenses))
+
+	quarantine, err := os.ReadFile(repair.File)
+	require.NoError(t, err)
+	assert.Contains(t, string(quarantine), "line 3: malformed: invalid date")
+	assert.Contains(t, string(quarantine), "\n2,20,Food,Dinner,2025-13-21\n")
+	assert.Contains(t, string(quarantine), "\n1,7,Food,Again,2025-04-23\n")
+
+	report, err = s.Check(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	t.Run("legacy format is kept", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte("1,10,Food,Lunch,2025-04-20\n\"2,broken\n"), 0644))
+		s := NewStorageFS(dir)
+
+		repair, err := s.Repair(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 1, repair.Quarantined)
+
+		data, err := os.ReadFile(filepath.Join(dir, "expenses.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "1,10,Food,Lunch,2025-04-20\n", string(data))
+
+		quarantine, err := os.ReadFile(repair.File)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(quarantine), "\n\"2,broken\n"))
+	})
+}
+
+func TestStorageFS_ListLenient(t *testing.T) {
+	dir := t.TempDir()
+	content := "#expense-tracker format 2\n1,10,Food,Lunch,2025-04-20\n2,20,Food,Dinner,2025-13-21\n3,x,Fun,Movie,2025-04-24\n4,8,Fun,Movie,2025-04-24\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(content), 0644))
+	s := NewStorageFS(dir)
+
+	_, err := s.List(t.Context())
+	assert.Error(t, err)
+
+	expenses, skipped, err := s.ListLenient(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 4}, ids(expenses))
+	require.Len(t, skipped, 2)
+	assert.Equal(t, 3, skipped[0].Line)
+	assert.Equal(t, IssueMalformed, skipped[0].Kind)
+	assert.Contains(t, skipped[0].Message, "invalid date")
+	assert.Equal(t, 4, skipped[1].Line)
+
+	t.Run("missing file", func(t *testing.T) {
+		expenses, skipped, err := NewStorageFS(t.TempDir()).ListLenient(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, expenses)
+		assert.Empty(t, skipped)
+	})
+}
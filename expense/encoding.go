@@ -1,25 +1,62 @@
 package expense
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
 )
 
+// extension holds the optional fields of an expense.
+// It is stored as a JSON object in an optional sixth column, so fields can be added
+// without changing the number of columns and unknown fields are ignored when decoding.
+type extension struct {
+	Kind     Kind              `json:"kind,omitempty"`
+	Notes    string            `json:"notes,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Account  string            `json:"account,omitempty"`
+	Payee    string            `json:"payee,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Splits   []Split           `json:"splits,omitempty"`
+	PaidBy   string            `json:"paid_by,omitempty"`
+	Shares   []Share           `json:"shares,omitempty"`
+	RefundOf int               `json:"refund_of,omitempty"`
+}
+
 // encode encodes an Expense into a slice of strings.
+// The extension column is only added if an optional field is set.
 func encode(expense Expense) []string {
-	return []string{
+	record := []string{
 		strconv.Itoa(expense.ID),
 		strconv.Itoa(expense.Amount),
 		expense.Category,
 		expense.Description,
 		expense.Date.Format(time.DateOnly),
 	}
+
+	ext := extension{
+		Kind:     expense.Kind,
+		Notes:    expense.Notes,
+		Metadata: expense.Metadata,
+		Account:  expense.Account,
+		Payee:    expense.Payee,
+		Tags:     expense.Tags,
+		Splits:   expense.Splits,
+		PaidBy:   expense.PaidBy,
+		Shares:   expense.Shares,
+		RefundOf: expense.RefundOf,
+	}
+	// Marshaling the extension cannot fail, it only holds plain values
+	if data, _ := json.Marshal(ext); string(data) != "{}" {
+		record = append(record, string(data))
+	}
+
+	return record
 }
 
 // decode decodes a slice of strings into an [*Expense].
 func decode(record []string) (*Expense, error) {
-	if len(record) != 5 {
+	if len(record) != 5 && len(record) != 6 {
 		return nil, errors.New("unexpected record length")
 	}
 
@@ -40,11 +77,28 @@ func decode(record []string) (*Expense, error) {
 		return nil, errors.New("invalid date: not in the format YYYY-MM-DD")
 	}
 
+	var ext extension
+	if len(record) == 6 && record[5] != "" {
+		if err := json.Unmarshal([]byte(record[5]), &ext); err != nil {
+			return nil, errors.New("invalid extension: not a JSON object")
+		}
+	}
+
 	return &Expense{
 		ID:          id,
 		Amount:      amount,
 		Category:    category,
 		Description: description,
 		Date:        date,
+		Kind:        ext.Kind,
+		Notes:       ext.Notes,
+		Metadata:    ext.Metadata,
+		Account:     ext.Account,
+		Payee:       ext.Payee,
+		Tags:        ext.Tags,
+		Splits:      ext.Splits,
+		PaidBy:      ext.PaidBy,
+		Shares:      ext.Shares,
+		RefundOf:    ext.RefundOf,
 	}, nil
 }
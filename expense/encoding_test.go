@@ -43,8 +43,13 @@ func TestDecode(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, expense)
 	})
-	t.Run("slice length not 5", func(t *testing.T) {
-		expense, err := decode([]string{"1", "10", "Food", "Lunch", "2025-10-10", ""})
+	t.Run("slice length not 5 or 6", func(t *testing.T) {
+		expense, err := decode([]string{"1", "10", "Food", "Lunch", "2025-10-10", "", ""})
+		assert.Error(t, err)
+		assert.Nil(t, expense)
+	})
+	t.Run("invalid extension", func(t *testing.T) {
+		expense, err := decode([]string{"1", "10", "Food", "Lunch", "2025-10-10", "not json"})
 		assert.Error(t, err)
 		assert.Nil(t, expense)
 	})
@@ -67,4 +72,35 @@ func TestDecode(t *testing.T) {
 		}
 		assert.Equal(t, want, *expense)
 	})
+
+	t.Run("successful decoding with extension", func(t *testing.T) {
+		expense, err := decode([]string{
+			"1",
+			"10",
+			"Food",
+			"Lunch",
+			"2025-04-15",
+			`{"notes":"Team\nlunch","metadata":{"project":"apollo"},"unknown":true}`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Team\nlunch", expense.Notes)
+		assert.Equal(t, map[string]string{"project": "apollo"}, expense.Metadata)
+	})
+}
+
+func TestEncode_Extension(t *testing.T) {
+	expense := Expense{
+		ID:          1,
+		Amount:      10,
+		Category:    "Food",
+		Description: "Lunch",
+		Date:        time.Date(2025, time.April, 15, 0, 0, 0, 0, time.UTC),
+		Notes:       "Team lunch",
+		Kind:        KindIncome,
+		Metadata:    map[string]string{"invoice": "INV-7"},
+	}
+
+	decoded, err := decode(encode(expense))
+	require.NoError(t, err)
+	assert.Equal(t, expense, *decoded)
 }
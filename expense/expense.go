@@ -2,6 +2,10 @@ package expense
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -9,11 +13,60 @@ import (
 
 // Expense represents a single expense entry
 type Expense struct {
-	ID          int       // Unique identifier for the expense
-	Amount      int       // Amount of the expense in dollars
-	Category    string    // Category of the expense
-	Date        time.Time // Date of the expense
-	Description string    // Description of the expense
+	ID          int       `json:"id"`          // Unique identifier for the expense
+	Amount      int       `json:"amount"`      // Amount of the expense in dollars
+	Category    string    `json:"category"`    // Category of the expense
+	Date        time.Time `json:"date"`        // Date of the expense
+	Description string    `json:"description"` // Description of the expense
+
+	Kind     Kind              `json:"kind,omitempty"`      // Kind of the transaction, empty for an expense
+	Notes    string            `json:"notes,omitempty"`     // Optional multi-line notes
+	Metadata map[string]string `json:"metadata,omitempty"`  // Optional key/value metadata, e.g. project=apollo
+	Account  string            `json:"account,omitempty"`   // Optional name of the account the expense was paid with
+	Payee    string            `json:"payee,omitempty"`     // Optional merchant or person the expense was paid to
+	Tags     []string          `json:"tags,omitempty"`      // Optional lowercase tags
+	Splits   []Split           `json:"splits,omitempty"`    // Optional parts attributed to their own categories, summing to the amount
+	PaidBy   string            `json:"paid_by,omitempty"`   // Optional person who paid a shared expense
+	Shares   []Share           `json:"shares,omitempty"`    // Optional parts owed by the people sharing the expense, summing to the amount
+	RefundOf int               `json:"refund_of,omitempty"` // ID of the expense refunded by this one, 0 if it's not a refund
+}
+
+// Equal reports whether both expenses hold the same data.
+// Dates are compared by calendar day since only the day of an expense is stored.
+func (e Expense) Equal(other Expense) bool {
+	return e.ID == other.ID &&
+		e.Amount == other.Amount &&
+		e.Category == other.Category &&
+		e.Description == other.Description &&
+		e.Kind == other.Kind &&
+		e.Date.Format(time.DateOnly) == other.Date.Format(time.DateOnly) &&
+		e.Notes == other.Notes &&
+		maps.Equal(e.Metadata, other.Metadata) &&
+		e.Account == other.Account &&
+		e.Payee == other.Payee &&
+		slices.Equal(e.Tags, other.Tags) &&
+		slices.Equal(e.Splits, other.Splits) &&
+		e.PaidBy == other.PaidBy &&
+		slices.Equal(e.Shares, other.Shares) &&
+		e.RefundOf == other.RefundOf
+}
+
+// IsExpense reports whether the transaction is money spent, as opposed to income or a transfer
+func (e Expense) IsExpense() bool {
+	return e.Kind == "" || e.Kind == KindExpense
+}
+
+// IsRefund reports whether the expense refunds part of an earlier expense
+func (e Expense) IsRefund() bool {
+	return e.RefundOf != 0
+}
+
+// NetAmount returns the amount the expense adds to the totals, negative for a refund
+func (e Expense) NetAmount() int {
+	if e.IsRefund() {
+		return -e.Amount
+	}
+	return e.Amount
 }
 
 // ValidateID validates the ID of an expense
@@ -57,3 +110,92 @@ func ParseDescription(description string) (string, error) {
 
 	return description, nil
 }
+
+// ParseNotes parses multi-line notes and returns an error if they are invalid.
+// Empty notes are allowed since notes are optional.
+func ParseNotes(notes string) (string, error) {
+	notes = strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+
+	if runeCount := utf8.RuneCountInString(notes); runeCount > 2000 {
+		return "", errors.New("invalid expense notes: must not exceed 2000 characters")
+	}
+
+	return notes, nil
+}
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,50}$`)
+
+// ParseMetadataPair parses a key=value pair and returns an error if it's invalid
+func ParseMetadataPair(pair string) (key, value string, err error) {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid expense metadata %q: must be in the form key=value", pair)
+	}
+
+	key = strings.TrimSpace(key)
+	if !metadataKeyPattern.MatchString(key) {
+		return "", "", fmt.Errorf("invalid expense metadata key %q: must be 1 to 50 letters, digits, '.', '-' or '_'", key)
+	}
+
+	value = strings.TrimSpace(value)
+	if runeCount := utf8.RuneCountInString(value); runeCount == 0 {
+		return "", "", fmt.Errorf("invalid expense metadata %q: value must not be empty", key)
+	} else if runeCount > 255 {
+		return "", "", fmt.Errorf("invalid expense metadata %q: value must not exceed 255 characters", key)
+	}
+
+	return key, value, nil
+}
+
+// ParseMetadata parses key=value pairs and returns an error if any of them is invalid or a key is repeated
+func ParseMetadata(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	metadata := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, err := ParseMetadataPair(pair)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := metadata[key]; ok {
+			return nil, fmt.Errorf("invalid expense metadata %q: key is repeated", key)
+		}
+		metadata[key] = value
+	}
+
+	return metadata, nil
+}
+
+// ParseTag parses a tag and returns an error if it's invalid, tags are lowercased
+func ParseTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if runeCount := utf8.RuneCountInString(tag); runeCount == 0 {
+		return "", errors.New("invalid expense tag: must not be empty")
+	} else if runeCount > 30 {
+		return "", errors.New("invalid expense tag: must not exceed 30 characters")
+	}
+
+	if strings.ContainsAny(tag, ", \t\n") {
+		return "", fmt.Errorf("invalid expense tag %q: must not contain commas or spaces", tag)
+	}
+
+	return tag, nil
+}
+
+// ParseTags parses tags, dropping repeated ones, and returns an error if any of them is invalid
+func ParseTags(tags []string) ([]string, error) {
+	var parsed []string
+	for _, tag := range tags {
+		tag, err := ParseTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(parsed, tag) {
+			parsed = append(parsed, tag)
+		}
+	}
+	return parsed, nil
+}
@@ -1,6 +1,7 @@
 package expense
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,3 +65,60 @@ func TestParseDescription(t *testing.T) {
 		assert.Equal(t, "food", d)
 	})
 }
+
+func TestParseNotes(t *testing.T) {
+	t.Run("allows empty notes", func(t *testing.T) {
+		n, err := ParseNotes("   ")
+		assert.NoError(t, err)
+		assert.Equal(t, "", n)
+	})
+	t.Run("fails with too long notes", func(t *testing.T) {
+		_, err := ParseNotes(strings.Repeat("a", 2001))
+		assert.Error(t, err)
+	})
+	t.Run("normalizes line endings", func(t *testing.T) {
+		n, err := ParseNotes(" first\r\nsecond ")
+		assert.NoError(t, err)
+		assert.Equal(t, "first\nsecond", n)
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("fails without separator", func(t *testing.T) {
+		_, err := ParseMetadata([]string{"project"})
+		assert.Error(t, err)
+	})
+	t.Run("fails with invalid key", func(t *testing.T) {
+		_, err := ParseMetadata([]string{"my project=apollo"})
+		assert.Error(t, err)
+	})
+	t.Run("fails with empty value", func(t *testing.T) {
+		_, err := ParseMetadata([]string{"project= "})
+		assert.Error(t, err)
+	})
+	t.Run("fails with repeated key", func(t *testing.T) {
+		_, err := ParseMetadata([]string{"project=apollo", "project=gemini"})
+		assert.Error(t, err)
+	})
+	t.Run("parses pairs", func(t *testing.T) {
+		m, err := ParseMetadata([]string{" project = apollo ", "invoice=INV-7=a"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"project": "apollo", "invoice": "INV-7=a"}, m)
+	})
+}
+
+func TestParseTags(t *testing.T) {
+	t.Run("fails with space in tag", func(t *testing.T) {
+		_, err := ParseTags([]string{"work trip"})
+		assert.Error(t, err)
+	})
+	t.Run("fails with empty tag", func(t *testing.T) {
+		_, err := ParseTags([]string{" "})
+		assert.Error(t, err)
+	})
+	t.Run("lowercases and drops repeated tags", func(t *testing.T) {
+		tags, err := ParseTags([]string{"Work", " travel ", "work"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"work", "travel"}, tags)
+	})
+}
@@ -0,0 +1,114 @@
+package expense
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Filter selects expenses, the zero value selects all expenses
+type Filter struct {
+	// Metadata selects expenses having all the keys with the given values.
+	// An empty value selects expenses having the key with any value.
+	Metadata map[string]string
+
+	// Account selects expenses paid with the account, compared case-insensitively
+	Account string
+
+	// Payee selects expenses paid to the payee, compared case-insensitively
+	Payee string
+
+	// Tag selects expenses having the tag
+	Tag string
+
+	// Category selects expenses in the category or having a split in it, compared case-insensitively
+	Category string
+
+	// From and To select expenses dated on or after From and on or before To, compared by day.
+	// A zero time leaves the range open.
+	From, To time.Time
+
+	// Search selects expenses whose description, payee or notes contain the text, ignoring case
+	Search string
+}
+
+// IsZero reports whether the filter selects all expenses
+func (f Filter) IsZero() bool {
+	return len(f.Metadata) == 0 && f.Account == "" && f.Payee == "" && f.Tag == "" &&
+		f.Category == "" && f.From.IsZero() && f.To.IsZero() && f.Search == ""
+}
+
+// Match reports whether the expense is selected by the filter
+func (f Filter) Match(expense Expense) bool {
+	if f.Account != "" && !strings.EqualFold(f.Account, expense.Account) {
+		return false
+	}
+
+	if f.Payee != "" && !strings.EqualFold(f.Payee, expense.Payee) {
+		return false
+	}
+
+	if f.Tag != "" && !slices.Contains(expense.Tags, strings.ToLower(f.Tag)) {
+		return false
+	}
+
+	if f.Category != "" && !strings.EqualFold(f.Category, expense.Category) &&
+		!slices.ContainsFunc(expense.Splits, func(s Split) bool { return strings.EqualFold(f.Category, s.Category) }) {
+		return false
+	}
+
+	day := expense.Date.Format(time.DateOnly)
+	if !f.From.IsZero() && day < f.From.Format(time.DateOnly) {
+		return false
+	}
+	if !f.To.IsZero() && day > f.To.Format(time.DateOnly) {
+		return false
+	}
+
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(expense.Description), search) &&
+			!strings.Contains(strings.ToLower(expense.Payee), search) &&
+			!strings.Contains(strings.ToLower(expense.Notes), search) {
+			return false
+		}
+	}
+
+	for key, value := range f.Metadata {
+		actual, ok := expense.Metadata[key]
+		if !ok || (value != "" && actual != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns the expenses selected by the filter
+func (f Filter) Apply(expenses []Expense) []Expense {
+	selected := make([]Expense, 0, len(expenses))
+	for _, expense := range expenses {
+		if f.Match(expense) {
+			selected = append(selected, expense)
+		}
+	}
+	return selected
+}
+
+// scanSlice calls fn for each of the expenses selected by the filter, stopping at the first error
+// returned by fn or when ctx is done. It implements Scan for storages holding the expenses in memory.
+func scanSlice(ctx context.Context, expenses []Expense, filter Filter, fn func(Expense) error) error {
+	for _, expense := range expenses {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !filter.Match(expense) {
+			continue
+		}
+		if err := fn(expense); err != nil {
+			return err
+		}
+	}
+	return nil
+}
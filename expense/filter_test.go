@@ -0,0 +1,53 @@
+package expense
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Apply(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Metadata: map[string]string{"project": "apollo", "invoice": "INV-7"}},
+		{ID: 2, Metadata: map[string]string{"project": "gemini"}},
+		{ID: 3},
+	}
+
+	t.Run("zero filter selects all", func(t *testing.T) {
+		assert.Equal(t, expenses, Filter{}.Apply(expenses))
+	})
+	t.Run("metadata value", func(t *testing.T) {
+		f := Filter{Metadata: map[string]string{"project": "apollo"}}
+		assert.Equal(t, expenses[:1], f.Apply(expenses))
+	})
+	t.Run("metadata key", func(t *testing.T) {
+		f := Filter{Metadata: map[string]string{"project": ""}}
+		assert.Equal(t, expenses[:2], f.Apply(expenses))
+	})
+}
+
+func TestFilter_DateCategorySearch(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Category: "Food", Description: "Lunch", Date: time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)},
+		{ID: 2, Category: "Misc", Description: "Ride", Payee: "Uber", Date: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, Category: "Groceries", Description: "Supermarket", Notes: "uber eats voucher", Date: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			Splits: []Split{{Category: "Groceries", Amount: 5}, {Category: "Food", Amount: 5}}},
+	}
+
+	assert.True(t, Filter{}.IsZero())
+	assert.False(t, Filter{Search: "uber"}.IsZero())
+
+	t.Run("date range is inclusive", func(t *testing.T) {
+		f := Filter{From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)}
+		assert.Equal(t, expenses[:2], f.Apply(expenses))
+	})
+	t.Run("category includes splits", func(t *testing.T) {
+		f := Filter{Category: "food"}
+		assert.Equal(t, []Expense{expenses[0], expenses[2]}, f.Apply(expenses))
+	})
+	t.Run("search description, payee and notes", func(t *testing.T) {
+		f := Filter{Search: "UBER"}
+		assert.Equal(t, expenses[1:], f.Apply(expenses))
+	})
+}
@@ -0,0 +1,315 @@
+package expense
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation is the kind of a journal entry
+type Operation string
+
+const (
+	OpAdd     Operation = "add"     // Expenses were added
+	OpDelete  Operation = "delete"  // Expenses were moved to the trash
+	OpEdit    Operation = "edit"    // Expenses were changed
+	OpUntrash Operation = "untrash" // Expenses were restored from the trash
+	OpReplace Operation = "replace" // All expenses were replaced, e.g. by restoring a backup
+	OpUndo    Operation = "undo"    // The referenced mutation was undone
+	OpRedo    Operation = "redo"    // The referenced mutation was redone
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// JournalEntry records a mutation of the expenses with their state before and after it,
+// or the undoing or redoing of an earlier mutation
+type JournalEntry struct {
+	Seq  int       `json:"seq"`           // Position of the entry in the journal, starting from 1
+	Time time.Time `json:"time"`          // Time the entry was recorded
+	Op   Operation `json:"op"`            // Kind of the entry
+	Ref  int       `json:"ref,omitempty"` // Seq of the mutation undone or redone
+
+	// Affected expenses before and after the mutation, a replacement only records
+	// the expenses it removed, added or changed
+	Before []Expense `json:"before,omitempty"`
+	After  []Expense `json:"after,omitempty"`
+
+	// ID counters around a replacement, which resets the counter
+	LastIDBefore int `json:"last_id_before,omitempty"`
+	LastIDAfter  int `json:"last_id_after,omitempty"`
+
+	Note string `json:"note,omitempty"` // Details of a change which is only audited, see [OpRepair]
+}
+
+// ids returns the IDs of the expenses
+func ids(expenses []Expense) []int {
+	ids := make([]int, len(expenses))
+	for i, expense := range expenses {
+		ids[i] = expense.ID
+	}
+	return ids
+}
+
+// diffExpenses returns the expenses removed or changed by replacing current with expenses as they were
+// before, and the ones added or changed as they are after
+func diffExpenses(current, expenses []Expense) (before, after []Expense) {
+	currentByID := make(map[int]Expense, len(current))
+	for _, expense := range current {
+		currentByID[expense.ID] = expense
+	}
+	byID := make(map[int]Expense, len(expenses))
+	for _, expense := range expenses {
+		byID[expense.ID] = expense
+	}
+
+	for _, expense := range current {
+		if other, ok := byID[expense.ID]; !ok || !expense.Equal(other) {
+			before = append(before, expense)
+		}
+	}
+	for _, expense := range expenses {
+		if other, ok := currentByID[expense.ID]; !ok || !expense.Equal(other) {
+			after = append(after, expense)
+		}
+	}
+	return before, after
+}
+
+// patchExpenses removes the expenses with the IDs of removed and adds the added ones,
+// returning the result ordered by ID
+func patchExpenses(expenses, removed, added []Expense) []Expense {
+	byID := make(map[int]Expense, len(expenses))
+	for _, expense := range expenses {
+		byID[expense.ID] = expense
+	}
+	for _, expense := range removed {
+		delete(byID, expense.ID)
+	}
+	for _, expense := range added {
+		byID[expense.ID] = expense
+	}
+
+	patched := slices.Collect(maps.Values(byID))
+	slices.SortFunc(patched, func(a, b Expense) int { return cmp.Compare(a.ID, b.ID) })
+	return patched
+}
+
+// Describe summarizes the mutation in a few words
+func (e JournalEntry) Describe() string {
+	switch e.Op {
+	case OpAdd:
+		return fmt.Sprintf("add of expenses %v", ids(e.After))
+	case OpDelete:
+		return fmt.Sprintf("delete of expenses %v", ids(e.Before))
+	case OpEdit:
+		return fmt.Sprintf("edit of expenses %v", ids(e.After))
+	case OpUntrash:
+		return fmt.Sprintf("restore of expenses %v from the trash", ids(e.After))
+	case OpReplace:
+		changed := slices.Compact(slices.Sorted(slices.Values(append(ids(e.Before), ids(e.After)...))))
+		return fmt.Sprintf("replacement of all expenses changing %d of them", len(changed))
+	default:
+		return fmt.Sprintf("%s of entry %d", e.Op, e.Ref)
+	}
+}
+
+// History splits the mutations of the journal into the ones which can be undone and the
+// ones which can be redone, the next one to undo or redo last. A new mutation after an undo
+// discards the undone mutations, as in an editor.
+func History(entries []JournalEntry) (undoable, redoable []JournalEntry) {
+	bySeq := make(map[int]JournalEntry, len(entries))
+	for _, entry := range entries {
+		bySeq[entry.Seq] = entry
+
+		switch entry.Op {
+		case OpUndo:
+			if n := len(undoable); n > 0 && undoable[n-1].Seq == entry.Ref {
+				undoable = undoable[:n-1]
+				redoable = append(redoable, bySeq[entry.Ref])
+			}
+		case OpRedo:
+			if n := len(redoable); n > 0 && redoable[n-1].Seq == entry.Ref {
+				redoable = redoable[:n-1]
+				undoable = append(undoable, bySeq[entry.Ref])
+			}
+		default:
+			undoable = append(undoable, entry)
+			redoable = nil
+		}
+	}
+	return undoable, redoable
+}
+
+// Rewind reverts the entries recorded after t on the current expenses, most recent first,
+// and returns the expenses as they were at t ordered by ID. Changes made before the journal
+// was kept cannot be reverted.
+func Rewind(expenses []Expense, entries []JournalEntry, t time.Time) []Expense {
+	rewound := patchExpenses(expenses, nil, nil)
+	for i := len(entries) - 1; i >= 0 && entries[i].Time.After(t); i-- {
+		rewound = patchExpenses(rewound, entries[i].After, entries[i].Before)
+	}
+	return rewound
+}
+
+// Journal records the mutations of the expenses
+type Journal interface {
+	Append(entry JournalEntry) error      // Appends the entry, assigning its sequence number and time.
+	Entries() ([]JournalEntry, error)     // Lists all entries in the order they were appended.
+	Replace(entries []JournalEntry) error // Replaces all entries, keeping their sequence numbers and times.
+}
+
+// JournalFS appends the journal entries as JSON lines to a file in the data directory.
+// The last sequence number is kept in memory and in a counter file, so appending does not read the journal.
+type JournalFS struct {
+	file    string
+	seqfile string
+	seq     int  // Last sequence number
+	loaded  bool // Whether seq was loaded
+}
+
+// NewJournalFS creates a new JournalFS keeping the journal in dirname
+func NewJournalFS(dirname string) *JournalFS {
+	return &JournalFS{
+		file:    filepath.Join(dirname, "journal.jsonl"),
+		seqfile: filepath.Join(dirname, "journal-seq.txt"),
+	}
+}
+
+// lastSeq returns the last sequence number. Journals kept before the counter file,
+// or whose counter file is unreadable, are read once to find it.
+func (j *JournalFS) lastSeq() (int, error) {
+	if j.loaded {
+		return j.seq, nil
+	}
+
+	data, err := os.ReadFile(j.seqfile)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if seq, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+		j.seq, j.loaded = seq, true
+		return seq, nil
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		return 0, err
+	}
+	j.seq, j.loaded = 0, true
+	if len(entries) > 0 {
+		j.seq = entries[len(entries)-1].Seq
+	}
+	return j.seq, nil
+}
+
+// setSeq writes the last sequence number to the counter file and keeps it in memory
+func (j *JournalFS) setSeq(seq int) error {
+	if err := os.WriteFile(j.seqfile, []byte(strconv.Itoa(seq)), 0644); err != nil {
+		return err
+	}
+	j.seq, j.loaded = seq, true
+	return nil
+}
+
+// Entries lists all entries, a missing file results in no entries
+func (j *JournalFS) Entries() ([]JournalEntry, error) {
+	file, err := os.Open(j.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []JournalEntry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []JournalEntry{}
+	scanner := bufio.NewScanner(file)
+	// Entries of replacements hold all expenses and may be long
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry on line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Append appends the entry as a single line
+func (j *JournalFS) Append(entry JournalEntry) error {
+	seq, err := j.lastSeq()
+	if err != nil {
+		return err
+	}
+
+	// The counter is written first, an interrupted append skips a number instead of reusing it
+	entry.Seq = seq + 1
+	entry.Time = time.Now()
+	if err := j.setSeq(entry.Seq); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Replace replaces all entries, e.g. by the journal of a backup
+func (j *JournalFS) Replace(entries []JournalEntry) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.file), "journal-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.file); err != nil {
+		return err
+	}
+
+	var seq int
+	if len(entries) > 0 {
+		seq = entries[len(entries)-1].Seq
+	}
+	return j.setSeq(seq)
+}
+
+var _ Journal = (*JournalFS)(nil)
@@ -0,0 +1,146 @@
+package expense
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJournal struct {
+	entries []JournalEntry
+}
+
+func (m *mockJournal) Append(entry JournalEntry) error {
+	entry.Seq = len(m.entries) + 1
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *mockJournal) Entries() ([]JournalEntry, error) {
+	return m.entries, nil
+}
+
+func (m *mockJournal) Replace(entries []JournalEntry) error {
+	m.entries = entries
+	return nil
+}
+
+func TestJournalFS(t *testing.T) {
+	j := NewJournalFS(t.TempDir())
+
+	entries, err := j.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	added := Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch"}
+	require.NoError(t, j.Append(JournalEntry{Op: OpAdd, After: []Expense{added}}))
+	require.NoError(t, j.Append(JournalEntry{Op: OpUndo, Ref: 1}))
+
+	entries, err = j.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 1, entries[0].Seq)
+	assert.Equal(t, OpAdd, entries[0].Op)
+	assert.True(t, added.Equal(entries[0].After[0]))
+	assert.Equal(t, 2, entries[1].Seq)
+	assert.Equal(t, 1, entries[1].Ref)
+	assert.False(t, entries[1].Time.IsZero())
+
+	t.Run("replace", func(t *testing.T) {
+		require.NoError(t, j.Replace(entries[:1]))
+		require.NoError(t, j.Append(JournalEntry{Op: OpDelete, Before: []Expense{added}}))
+
+		replaced, err := j.Entries()
+		require.NoError(t, err)
+		require.Len(t, replaced, 2)
+		assert.True(t, entries[0].Time.Equal(replaced[0].Time))
+		assert.Equal(t, 2, replaced[1].Seq, "the sequence continues after the replaced entries")
+	})
+}
+
+func TestJournalFS_Counter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "journal.jsonl"), []byte(`{"seq":1,"op":"add"}`+"\n"+`{"seq":2,"op":"delete"}`+"\n"), 0644))
+
+	j := NewJournalFS(dir)
+	require.NoError(t, j.Append(JournalEntry{Op: OpEdit}))
+	seq, err := os.ReadFile(filepath.Join(dir, "journal-seq.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(seq), "a missing counter is recovered from the journal")
+
+	// Appending relies on the counter and does not read the journal
+	file, err := os.OpenFile(filepath.Join(dir, "journal.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	require.NoError(t, NewJournalFS(dir).Append(JournalEntry{Op: OpEdit}))
+	seq, err = os.ReadFile(filepath.Join(dir, "journal-seq.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "4", string(seq))
+}
+
+func TestDiffExpenses(t *testing.T) {
+	lunch := Expense{ID: 1, Amount: 10, Category: "Food"}
+	dinner := Expense{ID: 2, Amount: 30, Category: "Food"}
+	edited := dinner
+	edited.Amount = 35
+	rent := Expense{ID: 3, Amount: 900, Category: "Rent"}
+
+	current := []Expense{lunch, dinner}
+	replacing := []Expense{lunch, edited, rent}
+	before, after := diffExpenses(current, replacing)
+	assert.Equal(t, []Expense{dinner}, before, "unchanged expenses are left out")
+	assert.Equal(t, []Expense{edited, rent}, after)
+
+	assert.Equal(t, replacing, patchExpenses(current, before, after))
+	assert.Equal(t, current, patchExpenses(replacing, after, before))
+}
+
+func TestHistory(t *testing.T) {
+	entries := []JournalEntry{
+		{Seq: 1, Op: OpAdd},
+		{Seq: 2, Op: OpEdit},
+		{Seq: 3, Op: OpDelete},
+		{Seq: 4, Op: OpUndo, Ref: 3},
+		{Seq: 5, Op: OpUndo, Ref: 2},
+		{Seq: 6, Op: OpRedo, Ref: 2},
+	}
+
+	undoable, redoable := History(entries)
+	assert.Equal(t, []JournalEntry{entries[0], entries[1]}, undoable)
+	assert.Equal(t, []JournalEntry{entries[2]}, redoable)
+
+	t.Run("new mutation discards undone ones", func(t *testing.T) {
+		undoable, redoable := History(append(entries, JournalEntry{Seq: 7, Op: OpAdd}))
+		assert.Len(t, undoable, 3)
+		assert.Empty(t, redoable)
+	})
+}
+
+func TestRewind(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	lunch := Expense{ID: 1, Amount: 10, Category: "Food"}
+	dinner := Expense{ID: 2, Amount: 30, Category: "Food"}
+	edited := dinner
+	edited.Amount = 35
+
+	entries := []JournalEntry{
+		{Seq: 1, Time: start, Op: OpAdd, After: []Expense{lunch}},
+		{Seq: 2, Time: start.Add(time.Hour), Op: OpAdd, After: []Expense{dinner}},
+		{Seq: 3, Time: start.Add(2 * time.Hour), Op: OpEdit, Before: []Expense{dinner}, After: []Expense{edited}},
+		{Seq: 4, Time: start.Add(3 * time.Hour), Op: OpDelete, Before: []Expense{lunch}},
+		{Seq: 5, Time: start.Add(4 * time.Hour), Op: OpUndo, Ref: 4, After: []Expense{lunch}},
+	}
+	current := []Expense{edited, lunch}
+
+	assert.Equal(t, []Expense{lunch, edited}, Rewind(current, entries, start.Add(5*time.Hour)))
+	assert.Equal(t, []Expense{edited}, Rewind(current, entries, start.Add(3*time.Hour)))
+	assert.Equal(t, []Expense{lunch, dinner}, Rewind(current, entries, start.Add(time.Hour)))
+	assert.Empty(t, Rewind(current, entries, start.Add(-time.Minute)))
+}
@@ -0,0 +1,180 @@
+package expense
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the version of the format the expenses file is written in
+const FormatVersion = 2
+
+// formatHeader starts the first line of the expenses file, followed by the format version.
+// Files of version 1 have no header.
+const formatHeader = "#expense-tracker format "
+
+// formatHeaderLine returns the header line of the current format version
+func formatHeaderLine() string {
+	return formatHeader + strconv.Itoa(FormatVersion) + "\n"
+}
+
+var (
+	ErrFormatTooOld = errors.New("expenses were written by an earlier version of expense-tracker")
+	ErrFormatTooNew = errors.New("expenses were written by a newer version of expense-tracker")
+)
+
+// Migration upgrades the records of the expenses file from the previous format version
+type Migration struct {
+	Version     int                                          // Format version the migration upgrades to
+	Description string                                       // What the migration changes
+	Migrate     func(records [][]string) ([][]string, error) // Converts the records of the previous version
+}
+
+// migrations lists the migrations ordered by version, each one following the previous
+var migrations = []Migration{
+	{
+		Version:     2,
+		Description: "Add the format header and drop empty extension columns",
+		Migrate:     compactRecords,
+	},
+}
+
+// compactRecords decodes and encodes the records again, dropping extension columns without optional fields
+func compactRecords(records [][]string) ([][]string, error) {
+	for i, record := range records {
+		expense, err := decode(record)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		records[i] = encode(*expense)
+	}
+	return records, nil
+}
+
+// PendingMigrations returns the migrations upgrading a file of the format version to the current one.
+// If the version is newer than the current one, [ErrFormatTooNew] is returned.
+func PendingMigrations(version int) ([]Migration, error) {
+	if version > FormatVersion {
+		return nil, fmt.Errorf("%w: format version %d, this version supports up to %d", ErrFormatTooNew, version, FormatVersion)
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// VersionedStorage is implemented by storages keeping the expenses in a versioned format
+type VersionedStorage interface {
+	FormatVersion(ctx context.Context) (int, error)                              // Returns the format version the expenses are stored in.
+	Migrate(ctx context.Context) (applied []Migration, backup string, err error) // Upgrades the expenses to the current format version.
+}
+
+// readFormatVersion reads the format version from the header line, files without one are of version 1.
+// An empty file holds nothing to migrate and is of the current version.
+func readFormatVersion(r io.Reader) (int, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	if line == "" {
+		return FormatVersion, nil
+	}
+	if !strings.HasPrefix(line, "#") {
+		return 1, nil
+	}
+
+	v, ok := strings.CutPrefix(strings.TrimSpace(line), formatHeader)
+	version, err := strconv.Atoi(v)
+	if !ok || err != nil || version < 2 {
+		return 0, fmt.Errorf("invalid format header %q", strings.TrimSpace(line))
+	}
+	return version, nil
+}
+
+// FormatVersion returns the format version of the expenses file, the current one if there is no file yet
+func (s *StorageFS) FormatVersion(ctx context.Context) (int, error) {
+	file, err := os.Open(s.expensesfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FormatVersion, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	return readFormatVersion(file)
+}
+
+// checkFormat makes sure the expenses file is in the current format version. Files of an earlier version
+// are refused with [ErrFormatTooOld] until upgraded by [StorageFS.Migrate], newer or unknown ones are refused too.
+// It is called first by every operation, which also stops once ctx is done.
+func (s *StorageFS) checkFormat(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	version, err := s.FormatVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := PendingMigrations(version); err != nil {
+		return err
+	}
+	if version < FormatVersion {
+		return fmt.Errorf("%w: format version %d, run migrate run to upgrade them", ErrFormatTooOld, version)
+	}
+	return nil
+}
+
+// Migrate upgrades the expenses file in place to the current format version and returns the applied migrations.
+// The file is copied to a backup next to it first, whose path is returned.
+func (s *StorageFS) Migrate(ctx context.Context) ([]Migration, string, error) {
+	version, err := s.FormatVersion(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pending, err := PendingMigrations(version)
+	if err != nil || len(pending) == 0 {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(s.expensesfile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	backup := filepath.Join(filepath.Dir(s.expensesfile), fmt.Sprintf("expenses.v%d.%s.bak", version, time.Now().Format("20060102T150405")))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return nil, "", err
+	}
+
+	records, err := newReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, backup, err
+	}
+
+	for _, migration := range pending {
+		if records, err = migration.Migrate(records); err != nil {
+			return nil, backup, fmt.Errorf("migrating to format version %d: %w", migration.Version, err)
+		}
+	}
+
+	return pending, backup, s.writeRecords(records)
+}
+
+var _ VersionedStorage = (*StorageFS)(nil)
@@ -0,0 +1,129 @@
+package expense
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFormatVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		version int
+		wantErr bool
+	}{
+		{name: "empty", content: "", version: FormatVersion},
+		{name: "no header", content: "1,10,Food,Lunch,2025-04-20\n", version: 1},
+		{name: "header", content: "#expense-tracker format 2\n1,10,Food,Lunch,2025-04-20\n", version: 2},
+		{name: "newer", content: "#expense-tracker format 7\n", version: 7},
+		{name: "invalid header", content: "#something else\n", wantErr: true},
+		{name: "invalid version", content: "#expense-tracker format 1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := readFormatVersion(strings.NewReader(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	pending, err := PendingMigrations(1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 2, pending[0].Version)
+
+	pending, err = PendingMigrations(FormatVersion)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = PendingMigrations(FormatVersion + 1)
+	assert.ErrorIs(t, err, ErrFormatTooNew)
+}
+
+func TestStorageFS_Migrate(t *testing.T) {
+	dir := t.TempDir()
+	legacy := "1,10,Food,Lunch,2025-04-20,{}\n2,20,Food,Dinner,2025-04-21,\"{\"\"notes\"\":\"\"late\"\"}\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(legacy), 0644))
+	s := NewStorageFS(dir)
+
+	applied, backup, err := s.Migrate(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	data, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, legacy, string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "expenses.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "#expense-tracker format 2\n1,10,Food,Lunch,2025-04-20\n2,20,Food,Dinner,2025-04-21,\"{\"\"notes\"\":\"\"late\"\"}\"\n", string(data))
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, expenses, 2)
+	assert.Equal(t, "late", expenses[1].Notes)
+
+	applied, backup, err = s.Migrate(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Empty(t, backup)
+
+	t.Run("earlier format is refused until migrated", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(legacy), 0644))
+		s := NewStorageFS(dir)
+
+		_, err := s.List(t.Context())
+		assert.ErrorIs(t, err, ErrFormatTooOld)
+		assert.ErrorIs(t, s.Add(t.Context(), Expense{ID: 3}), ErrFormatTooOld)
+
+		data, err := os.ReadFile(filepath.Join(dir, "expenses.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, legacy, string(data))
+		backups, err := filepath.Glob(filepath.Join(dir, "expenses.v1.*.bak"))
+		require.NoError(t, err)
+		assert.Empty(t, backups, "nothing is upgraded implicitly")
+	})
+
+	t.Run("failed migration leaves the file untouched", func(t *testing.T) {
+		dir := t.TempDir()
+		broken := "1,10,Food,Lunch,not a date\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(broken), 0644))
+		s := NewStorageFS(dir)
+
+		_, backup, err := s.Migrate(t.Context())
+		assert.Error(t, err)
+		assert.NotEmpty(t, backup)
+		data, err := os.ReadFile(filepath.Join(dir, "expenses.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, broken, string(data))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte("#something else\n"), 0644))
+
+		_, err := s.List(t.Context())
+		assert.ErrorContains(t, err, "invalid format header")
+	})
+
+	t.Run("newer format", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte("#expense-tracker format 9\n"), 0644))
+
+		_, err := s.List(t.Context())
+		assert.ErrorIs(t, err, ErrFormatTooNew)
+		_, _, err = s.Migrate(t.Context())
+		assert.ErrorIs(t, err, ErrFormatTooNew)
+	})
+}
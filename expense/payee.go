@@ -0,0 +1,171 @@
+package expense
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrPayeeNotFound = errors.New("payee not found")
+
+// Payee is a merchant or person expenses are paid to
+type Payee struct {
+	Name string `json:"name"` // Normalized name of the payee, compared case-insensitively
+
+	// Patterns are case-insensitive regular expressions matching the raw payees
+	// normalized to the name, e.g. ^AMZN normalizes "AMZN MKTP US*2K4" to Amazon
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// ParsePayee parses a payee string and returns an error if it's invalid
+func ParsePayee(payee string) (string, error) {
+	payee = strings.TrimSpace(payee)
+
+	if runeCount := utf8.RuneCountInString(payee); runeCount == 0 {
+		return "", errors.New("invalid expense payee: must not be empty")
+	} else if runeCount > 100 {
+		return "", errors.New("invalid expense payee: must not exceed 100 characters")
+	}
+
+	return payee, nil
+}
+
+// ParsePayeePattern parses a normalization pattern and returns an error if it's not a valid regular expression
+func ParsePayeePattern(pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "", errors.New("invalid payee pattern: must not be empty")
+	}
+
+	if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+		return "", fmt.Errorf("invalid payee pattern %q: %w", pattern, err)
+	}
+
+	return pattern, nil
+}
+
+// matches reports whether the raw payee is the payee or matched by one of its patterns
+func (p Payee) matches(raw string) bool {
+	if strings.EqualFold(p.Name, raw) {
+		return true
+	}
+
+	for _, pattern := range p.Patterns {
+		// Patterns are validated when added
+		if re, err := regexp.Compile("(?i)" + pattern); err == nil && re.MatchString(raw) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PayeeRegistry stores the payees and their normalization patterns in a CSV file in the data directory
+type PayeeRegistry struct {
+	file string
+}
+
+// NewPayeeRegistry creates a new PayeeRegistry storing the payees in dirname
+func NewPayeeRegistry(dirname string) *PayeeRegistry {
+	return &PayeeRegistry{file: filepath.Join(dirname, "payees.txt")}
+}
+
+// List lists all payees in the order they were added
+func (r *PayeeRegistry) List() ([]Payee, error) {
+	file, err := os.Open(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Payee{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	payees := make([]Payee, len(records))
+	for i, record := range records {
+		if len(record) == 0 {
+			return nil, errors.New("unexpected payee record length")
+		}
+		payees[i] = Payee{Name: record[0], Patterns: record[1:]}
+	}
+
+	return payees, nil
+}
+
+// Replace replaces all stored payees
+func (r *PayeeRegistry) Replace(payees []Payee) error {
+	records := make([][]string, len(payees))
+	for i, payee := range payees {
+		records[i] = append([]string{payee.Name}, payee.Patterns...)
+	}
+
+	file, err := os.Create(r.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll(records)
+}
+
+// Add adds a payee, or adds the patterns to the payee if it exists
+func (r *PayeeRegistry) Add(name string, patterns ...string) error {
+	payees, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	for i, payee := range payees {
+		if strings.EqualFold(payee.Name, name) {
+			payees[i].Patterns = append(payees[i].Patterns, patterns...)
+			return r.Replace(payees)
+		}
+	}
+
+	return r.Replace(append(payees, Payee{Name: name, Patterns: patterns}))
+}
+
+// Delete deletes the payee with the name, expenses paid to it keep referring to it by name
+func (r *PayeeRegistry) Delete(name string) error {
+	payees, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	for i, payee := range payees {
+		if strings.EqualFold(payee.Name, name) {
+			return r.Replace(append(payees[:i], payees[i+1:]...))
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrPayeeNotFound, name)
+}
+
+// Normalize returns the name of the first payee matching the raw payee,
+// or the raw payee if none matches
+func (r *PayeeRegistry) Normalize(raw string) (string, error) {
+	payees, err := r.List()
+	if err != nil {
+		return "", err
+	}
+
+	for _, payee := range payees {
+		if payee.matches(raw) {
+			return payee.Name, nil
+		}
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,72 @@
+package expense
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayee(t *testing.T) {
+	t.Run("fails with space only", func(t *testing.T) {
+		p, err := ParsePayee("   ")
+		assert.Error(t, err)
+		assert.Equal(t, "", p)
+	})
+	t.Run("fails with too long payee", func(t *testing.T) {
+		_, err := ParsePayee(strings.Repeat("a", 101))
+		assert.Error(t, err)
+	})
+	t.Run("trims spaces on success", func(t *testing.T) {
+		p, err := ParsePayee("  Starbucks  ")
+		assert.NoError(t, err)
+		assert.Equal(t, "Starbucks", p)
+	})
+}
+
+func TestParsePayeePattern(t *testing.T) {
+	t.Run("fails with invalid expression", func(t *testing.T) {
+		_, err := ParsePayeePattern("(")
+		assert.Error(t, err)
+	})
+	t.Run("parses expression", func(t *testing.T) {
+		p, err := ParsePayeePattern(" ^AMZN ")
+		assert.NoError(t, err)
+		assert.Equal(t, "^AMZN", p)
+	})
+}
+
+func TestPayeeRegistry(t *testing.T) {
+	r := NewPayeeRegistry(t.TempDir())
+	require.NoError(t, r.Add("Amazon", "^AMZN"))
+	require.NoError(t, r.Add("amazon", `amazon\.com`))
+	require.NoError(t, r.Add("Starbucks"))
+
+	payees, err := r.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Payee{
+		{Name: "Amazon", Patterns: []string{"^AMZN", `amazon\.com`}},
+		{Name: "Starbucks", Patterns: []string{}},
+	}, payees)
+
+	for raw, want := range map[string]string{
+		"AMZN MKTP US*2K4":    "Amazon",
+		"amzn digital":        "Amazon",
+		"www.Amazon.com":      "Amazon",
+		"STARBUCKS":           "Starbucks",
+		"Starbucks Reserve":   "Starbucks Reserve",
+		"Corner Coffee House": "Corner Coffee House",
+	} {
+		got, err := r.Normalize(raw)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, raw)
+	}
+
+	require.NoError(t, r.Delete("AMAZON"))
+	assert.ErrorIs(t, r.Delete("Amazon"), ErrPayeeNotFound)
+
+	got, err := r.Normalize("AMZN MKTP US*2K4")
+	require.NoError(t, err)
+	assert.Equal(t, "AMZN MKTP US*2K4", got)
+}
@@ -0,0 +1,234 @@
+package expense
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrPersonNotFound = errors.New("person not found")
+	ErrPersonExists   = errors.New("person already exists")
+)
+
+// ParsePersonName parses the name of a person and returns an error if it's invalid
+func ParsePersonName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if runeCount := utf8.RuneCountInString(name); runeCount == 0 {
+		return "", errors.New("invalid person name: must not be empty")
+	} else if runeCount > 50 {
+		return "", errors.New("invalid person name: must not exceed 50 characters")
+	}
+
+	return name, nil
+}
+
+// PeopleRegistry stores the people sharing expenses in a CSV file in the data directory
+type PeopleRegistry struct {
+	file string
+}
+
+// NewPeopleRegistry creates a new PeopleRegistry storing the people in dirname
+func NewPeopleRegistry(dirname string) *PeopleRegistry {
+	return &PeopleRegistry{file: filepath.Join(dirname, "people.txt")}
+}
+
+// List lists all people in the order they were added
+func (r *PeopleRegistry) List() ([]string, error) {
+	file, err := os.Open(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	people := make([]string, len(records))
+	for i, record := range records {
+		if len(record) != 1 {
+			return nil, errors.New("unexpected person record length")
+		}
+		people[i] = record[0]
+	}
+
+	return people, nil
+}
+
+// Find returns the name of the person as registered, compared case-insensitively
+func (r *PeopleRegistry) Find(name string) (string, error) {
+	people, err := r.List()
+	if err != nil {
+		return "", err
+	}
+
+	for _, person := range people {
+		if strings.EqualFold(person, name) {
+			return person, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrPersonNotFound, name)
+}
+
+// Add adds a new person, failing if a person with the same name exists
+func (r *PeopleRegistry) Add(name string) error {
+	if _, err := r.Find(name); err == nil {
+		return fmt.Errorf("%w: %s", ErrPersonExists, name)
+	} else if !errors.Is(err, ErrPersonNotFound) {
+		return err
+	}
+
+	file, err := os.OpenFile(r.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0655)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll([][]string{{name}})
+}
+
+// Delete deletes the person with the name, expenses shared with them keep referring to them by name
+func (r *PeopleRegistry) Delete(name string) error {
+	people, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(people), func(person string) bool { return strings.EqualFold(person, name) })
+	if len(kept) == len(people) {
+		return fmt.Errorf("%w: %s", ErrPersonNotFound, name)
+	}
+
+	return r.Replace(kept)
+}
+
+// Replace replaces all stored people
+func (r *PeopleRegistry) Replace(people []string) error {
+	records := make([][]string, len(people))
+	for i, person := range people {
+		records[i] = []string{person}
+	}
+
+	file, err := os.Create(r.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll(records)
+}
+
+// Settlement is a payment from one person to another settling shared expenses
+type Settlement struct {
+	Date   time.Time `json:"date"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Amount int       `json:"amount"`
+}
+
+// SettlementLedger stores the settlements in a CSV file in the data directory
+type SettlementLedger struct {
+	file string
+}
+
+// NewSettlementLedger creates a new SettlementLedger storing the settlements in dirname
+func NewSettlementLedger(dirname string) *SettlementLedger {
+	return &SettlementLedger{file: filepath.Join(dirname, "settlements.txt")}
+}
+
+// List lists all settlements in the order they were recorded
+func (l *SettlementLedger) List() ([]Settlement, error) {
+	file, err := os.Open(l.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Settlement{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	settlements := make([]Settlement, len(records))
+	for i, record := range records {
+		if len(record) != 4 {
+			return nil, errors.New("unexpected settlement record length")
+		}
+
+		date, err := time.Parse(time.DateOnly, record[0])
+		if err != nil {
+			return nil, errors.New("invalid settlement date: not in the format YYYY-MM-DD")
+		}
+		amount, err := strconv.Atoi(record[3])
+		if err != nil {
+			return nil, errors.New("invalid settlement amount: not an integer")
+		}
+
+		settlements[i] = Settlement{Date: date, From: record[1], To: record[2], Amount: amount}
+	}
+
+	return settlements, nil
+}
+
+// Add records the settlements
+func (l *SettlementLedger) Add(settlements ...Settlement) error {
+	for _, settlement := range settlements {
+		if strings.EqualFold(settlement.From, settlement.To) {
+			return errors.New("invalid settlement: a person cannot settle with themselves")
+		}
+		if err := ValidateAmount(settlement.Amount); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0655)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return l.write(file, settlements)
+}
+
+// Replace replaces all recorded settlements
+func (l *SettlementLedger) Replace(settlements []Settlement) error {
+	file, err := os.Create(l.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return l.write(file, settlements)
+}
+
+// write writes the settlements as CSV
+func (l *SettlementLedger) write(w io.Writer, settlements []Settlement) error {
+	records := make([][]string, len(settlements))
+	for i, settlement := range settlements {
+		records[i] = []string{
+			settlement.Date.Format(time.DateOnly),
+			settlement.From,
+			settlement.To,
+			strconv.Itoa(settlement.Amount),
+		}
+	}
+	return csv.NewWriter(w).WriteAll(records)
+}
@@ -0,0 +1,54 @@
+package expense
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeopleRegistry(t *testing.T) {
+	r := NewPeopleRegistry(t.TempDir())
+
+	people, err := r.List()
+	require.NoError(t, err)
+	assert.Empty(t, people)
+
+	require.NoError(t, r.Add("Alice"))
+	require.NoError(t, r.Add("Bob"))
+	assert.ErrorIs(t, r.Add("alice"), ErrPersonExists)
+
+	name, err := r.Find("BOB")
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", name)
+
+	require.NoError(t, r.Delete("alice"))
+	assert.ErrorIs(t, r.Delete("alice"), ErrPersonNotFound)
+
+	people, err = r.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bob"}, people)
+}
+
+func TestSettlementLedger(t *testing.T) {
+	l := NewSettlementLedger(t.TempDir())
+
+	settlements, err := l.List()
+	require.NoError(t, err)
+	assert.Empty(t, settlements)
+
+	date := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Error(t, l.Add(Settlement{Date: date, From: "Bob", To: "bob", Amount: 5}))
+	assert.Error(t, l.Add(Settlement{Date: date, From: "Bob", To: "Alice", Amount: 0}))
+
+	require.NoError(t, l.Add(Settlement{Date: date, From: "Bob", To: "Alice", Amount: 5}))
+	require.NoError(t, l.Add(Settlement{Date: date, From: "Carol", To: "Alice", Amount: 7}))
+
+	settlements, err = l.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Settlement{
+		{Date: date, From: "Bob", To: "Alice", Amount: 5},
+		{Date: date, From: "Carol", To: "Alice", Amount: 7},
+	}, settlements)
+}
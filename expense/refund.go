@@ -0,0 +1,65 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrRefundExceedsExpense = errors.New("refunds exceed the amount of the expense")
+	ErrExpenseHasRefunds    = errors.New("expense has refunds")
+)
+
+// RefundedAmount returns the total amount refunded for the expense with the ID
+func RefundedAmount(expenses []Expense, id int) int {
+	var refunded int
+	for _, refund := range Refunds(expenses, id) {
+		refunded += refund.Amount
+	}
+	return refunded
+}
+
+// Refunds returns the refunds of the expense with the ID in the order they were added
+func Refunds(expenses []Expense, id int) []Expense {
+	var refunds []Expense
+	for _, expense := range expenses {
+		if expense.RefundOf == id {
+			refunds = append(refunds, expense)
+		}
+	}
+	return refunds
+}
+
+// checkRefunds checks that the refunds of the changed expenses, and of the expenses the changed refunds belong to,
+// do not exceed their amounts. The expenses hold the changes, the other expenses are not checked.
+func checkRefunds(expenses, changed []Expense) error {
+	for _, expense := range changed {
+		id := expense.ID
+		if expense.IsRefund() {
+			id = expense.RefundOf
+		}
+
+		i := slices.IndexFunc(expenses, func(e Expense) bool { return e.ID == id })
+		if i == -1 {
+			continue
+		}
+		if refunded := RefundedAmount(expenses, id); refunded > expenses[i].Amount {
+			return fmt.Errorf("%w: %d refunded of the %d of expense %d", ErrRefundExceedsExpense, refunded, expenses[i].Amount, id)
+		}
+	}
+	return nil
+}
+
+// checkDeletedRefunds checks that the refunds of the expenses with the IDs are deleted along with them,
+// so no refund is left referring to a deleted expense
+func checkDeletedRefunds(expenses []Expense, ids []int) error {
+	for _, id := range ids {
+		for _, refund := range Refunds(expenses, id) {
+			if !slices.Contains(ids, refund.ID) {
+				return fmt.Errorf("%w: expense %d is refunded by expense %d, delete the refunds along with it", ErrExpenseHasRefunds, id, refund.ID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package expense
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoCategory is returned when adding an expense without a category no rule assigns one to
+var ErrNoCategory = errors.New("no category given and no rule matched")
+
+// RuleConditions are the conditions an expense must meet for a rule to match.
+// Conditions left empty are ignored, a rule without conditions matches every expense.
+type RuleConditions struct {
+	Description string   `yaml:"description,omitempty"` // Case-insensitive regular expression matching the description
+	Payee       string   `yaml:"payee,omitempty"`       // Case-insensitive regular expression matching the payee
+	MinAmount   int      `yaml:"min_amount,omitempty"`  // Minimum amount, inclusive
+	MaxAmount   int      `yaml:"max_amount,omitempty"`  // Maximum amount, inclusive
+	Account     string   `yaml:"account,omitempty"`     // Account name, compared case-insensitively
+	Weekdays    []string `yaml:"weekdays,omitempty"`    // Days of the week of the expense date, e.g. saturday
+
+	description *regexp.Regexp
+	payee       *regexp.Regexp
+	weekdays    []time.Weekday
+}
+
+// RuleActions are the fields a matching rule assigns to an expense
+type RuleActions struct {
+	Category string   `yaml:"category"`        // Category to assign, required
+	Tags     []string `yaml:"tags,omitempty"`  // Tags to add
+	Payee    string   `yaml:"payee,omitempty"` // Payee to assign if the expense has none
+}
+
+// Rule assigns a category, tags and a payee to the expenses meeting its conditions
+type Rule struct {
+	Name  string         `yaml:"name"`
+	Match RuleConditions `yaml:"match"`
+	Set   RuleActions    `yaml:"set"`
+}
+
+// rulesFile is the layout of the rules file
+type rulesFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// parseWeekday parses the full or three letter English name of a weekday
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
+
+// compile validates the rule and prepares its conditions for matching
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("invalid rule: name must not be empty")
+	}
+
+	var err error
+	if r.Match.Description != "" {
+		if r.Match.description, err = regexp.Compile("(?i)" + r.Match.Description); err != nil {
+			return fmt.Errorf("invalid rule %s: description: %w", r.Name, err)
+		}
+	}
+	if r.Match.Payee != "" {
+		if r.Match.payee, err = regexp.Compile("(?i)" + r.Match.Payee); err != nil {
+			return fmt.Errorf("invalid rule %s: payee: %w", r.Name, err)
+		}
+	}
+
+	if r.Match.MinAmount < 0 || r.Match.MaxAmount < 0 || (r.Match.MaxAmount != 0 && r.Match.MaxAmount < r.Match.MinAmount) {
+		return fmt.Errorf("invalid rule %s: amount range", r.Name)
+	}
+
+	r.Match.weekdays = nil
+	for _, name := range r.Match.Weekdays {
+		weekday, err := parseWeekday(name)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s: %w", r.Name, err)
+		}
+		r.Match.weekdays = append(r.Match.weekdays, weekday)
+	}
+
+	if r.Set.Category, err = ParseCategory(r.Set.Category); err != nil {
+		return fmt.Errorf("invalid rule %s: %w", r.Name, err)
+	}
+	if r.Set.Tags, err = ParseTags(r.Set.Tags); err != nil {
+		return fmt.Errorf("invalid rule %s: %w", r.Name, err)
+	}
+	if r.Set.Payee != "" {
+		if r.Set.Payee, err = ParsePayee(r.Set.Payee); err != nil {
+			return fmt.Errorf("invalid rule %s: %w", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the expense meets all conditions of the rule
+func (r *Rule) Matches(expense Expense) bool {
+	m := r.Match
+	switch {
+	case m.description != nil && !m.description.MatchString(expense.Description):
+		return false
+	case m.payee != nil && !m.payee.MatchString(expense.Payee):
+		return false
+	case m.MinAmount != 0 && expense.Amount < m.MinAmount:
+		return false
+	case m.MaxAmount != 0 && expense.Amount > m.MaxAmount:
+		return false
+	case m.Account != "" && !strings.EqualFold(m.Account, expense.Account):
+		return false
+	case len(m.weekdays) > 0 && !slices.Contains(m.weekdays, expense.Date.Weekday()):
+		return false
+	}
+	return true
+}
+
+// Apply assigns the category of the rule to the expense, adds its tags and
+// assigns its payee if the expense has none
+func (r *Rule) Apply(expense *Expense) {
+	expense.Category = r.Set.Category
+
+	for _, tag := range r.Set.Tags {
+		if !slices.Contains(expense.Tags, tag) {
+			expense.Tags = append(expense.Tags, tag)
+		}
+	}
+
+	if expense.Payee == "" {
+		expense.Payee = r.Set.Payee
+	}
+}
+
+// MatchRule returns the first rule matching the expense, nil if none matches
+func MatchRule(rules []Rule, expense Expense) *Rule {
+	for i := range rules {
+		if rules[i].Matches(expense) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ParseRules reads and validates rules in YAML, rules are matched in the order they are listed
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var file rulesFile
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// RuleStorage provides the categorization rules
+type RuleStorage interface {
+	Rules() ([]Rule, error) // Returns the rules in the order they are matched.
+}
+
+// RulesFS reads the rules from a YAML file in the data directory
+type RulesFS struct {
+	file string
+}
+
+// NewRulesFS creates a new RulesFS reading the rules from dirname
+func NewRulesFS(dirname string) *RulesFS {
+	return &RulesFS{file: filepath.Join(dirname, "rules.yaml")}
+}
+
+// Path returns the path of the rules file
+func (r *RulesFS) Path() string {
+	return r.file
+}
+
+// Rules reads the rules, a missing file results in no rules
+func (r *RulesFS) Rules() ([]Rule, error) {
+	file, err := os.Open(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Rule{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseRules(file)
+}
+
+// Raw reads the rules file as it was written, with its comments, a missing file results in no content
+func (r *RulesFS) Raw() ([]byte, error) {
+	data, err := os.ReadFile(r.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// WriteRaw replaces the rules file with the data, which must hold valid rules.
+// Empty data removes the file.
+func (r *RulesFS) WriteRaw(data []byte) error {
+	if len(data) == 0 {
+		if err := os.Remove(r.file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if _, err := ParseRules(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return os.WriteFile(r.file, data, 0644)
+}
+
+var _ RuleStorage = (*RulesFS)(nil)
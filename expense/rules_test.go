@@ -0,0 +1,118 @@
+package expense
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `
+rules:
+  - name: weekend coffee
+    match:
+      description: latte|espresso
+      max_amount: 10
+      weekdays: [sat, Sunday]
+    set:
+      category: Treats
+      tags: [Caffeine, weekend]
+  - name: coffee
+    match:
+      description: latte|espresso
+    set:
+      category: Coffee
+      tags: [caffeine]
+      payee: Starbucks
+  - name: card
+    match:
+      account: amex
+      min_amount: 100
+    set:
+      category: Big purchases
+`
+
+func TestParseRules(t *testing.T) {
+	t.Run("empty input", func(t *testing.T) {
+		rules, err := ParseRules(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+	t.Run("fails without name", func(t *testing.T) {
+		_, err := ParseRules(strings.NewReader("rules:\n  - set:\n      category: Food\n"))
+		assert.Error(t, err)
+	})
+	t.Run("fails without category", func(t *testing.T) {
+		_, err := ParseRules(strings.NewReader("rules:\n  - name: food\n"))
+		assert.Error(t, err)
+	})
+	t.Run("fails with invalid expression", func(t *testing.T) {
+		_, err := ParseRules(strings.NewReader("rules:\n  - name: food\n    match:\n      description: \"(\"\n    set:\n      category: Food\n"))
+		assert.Error(t, err)
+	})
+	t.Run("fails with invalid weekday", func(t *testing.T) {
+		_, err := ParseRules(strings.NewReader("rules:\n  - name: food\n    match:\n      weekdays: [someday]\n    set:\n      category: Food\n"))
+		assert.Error(t, err)
+	})
+	t.Run("normalizes tags", func(t *testing.T) {
+		rules, err := ParseRules(strings.NewReader(testRules))
+		require.NoError(t, err)
+		require.Len(t, rules, 3)
+		assert.Equal(t, []string{"caffeine", "weekend"}, rules[0].Set.Tags)
+	})
+}
+
+func TestMatchRule(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+
+	saturday := time.Date(2025, time.April, 19, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name    string
+		expense Expense
+		want    string
+	}{
+		{"weekday condition", Expense{Description: "Oat LATTE", Amount: 5, Date: saturday}, "weekend coffee"},
+		{"amount condition", Expense{Description: "Latte", Amount: 15, Date: saturday}, "coffee"},
+		{"first rule not matching", Expense{Description: "espresso", Amount: 5, Date: monday}, "coffee"},
+		{"account and minimum amount", Expense{Description: "TV", Amount: 100, Account: "Amex", Date: monday}, "card"},
+		{"no rule", Expense{Description: "TV", Amount: 99, Account: "Amex", Date: monday}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := MatchRule(rules, tc.expense)
+			if tc.want == "" {
+				assert.Nil(t, rule)
+				return
+			}
+			require.NotNil(t, rule)
+			assert.Equal(t, tc.want, rule.Name)
+		})
+	}
+}
+
+func TestRule_Apply(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+
+	t.Run("keeps existing payee and tags", func(t *testing.T) {
+		expense := Expense{Category: "Misc", Payee: "Corner Cafe", Tags: []string{"caffeine", "work"}}
+		rules[1].Apply(&expense)
+		assert.Equal(t, Expense{Category: "Coffee", Payee: "Corner Cafe", Tags: []string{"caffeine", "work"}}, expense)
+	})
+	t.Run("assigns payee and tags", func(t *testing.T) {
+		expense := Expense{}
+		rules[1].Apply(&expense)
+		assert.Equal(t, Expense{Category: "Coffee", Payee: "Starbucks", Tags: []string{"caffeine"}}, expense)
+	})
+}
+
+func TestRulesFS(t *testing.T) {
+	r := NewRulesFS(t.TempDir())
+	rules, err := r.Rules()
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
@@ -1,64 +1,852 @@
 package expense
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
 
 type ExpenseService struct {
 	expenseStorage ExpenseStorage
+	ruleStorage    RuleStorage
+	journal        Journal
+	auditLog       AuditLog
 }
 
-func NewExpenseService(expenseStorage ExpenseStorage) *ExpenseService {
-	return &ExpenseService{
+// ServiceOption configures an optional dependency of the service
+type ServiceOption func(*ExpenseService)
+
+// WithRules sets the storage of the rules categorizing expenses added without a category
+func WithRules(ruleStorage RuleStorage) ServiceOption {
+	return func(s *ExpenseService) { s.ruleStorage = ruleStorage }
+}
+
+// WithJournal records every mutation of the expenses in the journal, allowing to undo and redo them
+func WithJournal(journal Journal) ServiceOption {
+	return func(s *ExpenseService) { s.journal = journal }
+}
+
+// WithAuditLog records who made every change of the expenses in the audit log
+func WithAuditLog(auditLog AuditLog) ServiceOption {
+	return func(s *ExpenseService) { s.auditLog = auditLog }
+}
+
+func NewExpenseService(expenseStorage ExpenseStorage, opts ...ServiceOption) *ExpenseService {
+	s := &ExpenseService{
 		expenseStorage: expenseStorage,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// AddExpense adds a new expense with category, description and amount for today
-func (s *ExpenseService) AddExpense(category, description string, amount int) (*Expense, error) {
-	id, err := s.expenseStorage.GenerateID()
-	if err != nil {
-		return nil, err
+// rules returns the categorization rules, none if no rule storage is set
+func (s *ExpenseService) rules() ([]Rule, error) {
+	if s.ruleStorage == nil {
+		return nil, nil
 	}
+	return s.ruleStorage.Rules()
+}
+
+// recording reports whether mutations are recorded in a journal or an audit log
+func (s *ExpenseService) recording() bool {
+	return s.journal != nil || s.auditLog != nil
+}
+
+// record appends the mutation to the journal and the audit log, if any
+func (s *ExpenseService) record(entry JournalEntry) error {
+	if s.journal != nil {
+		if err := s.journal.Append(entry); err != nil {
+			return fmt.Errorf("recording %s in the journal: %w", entry.Op, err)
+		}
+	}
+
+	if s.auditLog != nil {
+		if err := s.auditLog.Record(entry); err != nil {
+			return fmt.Errorf("recording %s in the audit log: %w", entry.Op, err)
+		}
+	}
+	return nil
+}
 
+// audit appends a change which cannot be undone to the audit log only, if any
+func (s *ExpenseService) audit(entry JournalEntry) error {
+	if s.auditLog == nil {
+		return nil
+	}
+	if err := s.auditLog.Record(entry); err != nil {
+		return fmt.Errorf("recording %s in the audit log: %w", entry.Op, err)
+	}
+	return nil
+}
+
+// ExpenseOption sets an optional field of a new expense
+type ExpenseOption func(*Expense)
+
+// WithKind sets the kind of the transaction, expenses are stored without one
+func WithKind(kind Kind) ExpenseOption {
+	return func(e *Expense) {
+		if kind == KindExpense {
+			kind = ""
+		}
+		e.Kind = kind
+	}
+}
+
+// WithCategory sets the category of the expense
+func WithCategory(category string) ExpenseOption {
+	return func(e *Expense) { e.Category = category }
+}
+
+// WithDescription sets the description of the expense
+func WithDescription(description string) ExpenseOption {
+	return func(e *Expense) { e.Description = description }
+}
+
+// WithAmount sets the amount of the expense
+func WithAmount(amount int) ExpenseOption {
+	return func(e *Expense) { e.Amount = amount }
+}
+
+// WithDate sets the date of the expense
+func WithDate(date time.Time) ExpenseOption {
+	return func(e *Expense) { e.Date = date }
+}
+
+// WithAddedTags adds the tags the expense does not have yet
+func WithAddedTags(tags []string) ExpenseOption {
+	return func(e *Expense) {
+		for _, tag := range tags {
+			if !slices.Contains(e.Tags, tag) {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+	}
+}
+
+// WithoutTags removes the tags from the expense
+func WithoutTags(tags []string) ExpenseOption {
+	return func(e *Expense) {
+		e.Tags = slices.DeleteFunc(e.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+	}
+}
+
+// WithNotes sets the notes of the expense
+func WithNotes(notes string) ExpenseOption {
+	return func(e *Expense) { e.Notes = notes }
+}
+
+// WithMetadata sets the key/value metadata of the expense
+func WithMetadata(metadata map[string]string) ExpenseOption {
+	return func(e *Expense) { e.Metadata = metadata }
+}
+
+// WithAccount sets the name of the account the expense was paid with
+func WithAccount(account string) ExpenseOption {
+	return func(e *Expense) { e.Account = account }
+}
+
+// WithPayee sets the merchant or person the expense was paid to
+func WithPayee(payee string) ExpenseOption {
+	return func(e *Expense) { e.Payee = payee }
+}
+
+// WithTags sets the tags of the expense
+func WithTags(tags []string) ExpenseOption {
+	return func(e *Expense) { e.Tags = tags }
+}
+
+// WithSplits splits the expense into parts attributed to their own categories
+func WithSplits(splits []Split) ExpenseOption {
+	return func(e *Expense) { e.Splits = splits }
+}
+
+// WithShares shares the expense paid by paidBy between the people owing the shares
+func WithShares(paidBy string, shares []Share) ExpenseOption {
+	return func(e *Expense) {
+		e.PaidBy = paidBy
+		e.Shares = shares
+	}
+}
+
+// AddExpense adds a new expense with category, description, amount and optional fields for today.
+// If the category is empty, a split expense takes the category of its largest split, otherwise
+// the first matching rule categorizes the expense and [ErrNoCategory] is returned if none matches.
+func (s *ExpenseService) AddExpense(ctx context.Context, category, description string, amount int, opts ...ExpenseOption) (*Expense, error) {
 	expense := Expense{
-		ID:          id,
 		Date:        time.Now(),
 		Category:    category,
 		Description: description,
 		Amount:      amount,
 	}
+	for _, opt := range opts {
+		opt(&expense)
+	}
+
+	if err := ValidateSplits(expense.Splits, expense.Amount); err != nil {
+		return nil, err
+	}
+	if err := ValidateShares(expense.PaidBy, expense.Shares, expense.Amount); err != nil {
+		return nil, err
+	}
+	if expense.Category == "" && len(expense.Splits) > 0 {
+		expense.Category = largestSplit(expense.Splits).Category
+	}
+
+	if expense.Category == "" {
+		rules, err := s.rules()
+		if err != nil {
+			return nil, err
+		}
+
+		rule := MatchRule(rules, expense)
+		if rule == nil {
+			return nil, ErrNoCategory
+		}
+		rule.Apply(&expense)
+	}
+
+	id, err := s.expenseStorage.GenerateID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expense.ID = id
 
-	if err := s.expenseStorage.Add(expense); err != nil {
+	if err := s.expenseStorage.Add(ctx, expense); err != nil {
+		return nil, err
+	}
+	if err := s.record(JournalEntry{Op: OpAdd, After: []Expense{expense}}); err != nil {
 		return nil, err
 	}
 
 	return &expense, nil
 }
 
-// DeleteExpense deletes an expense by its ID
-func (s *ExpenseService) DeleteExpense(id int) error {
-	return s.expenseStorage.Delete(id)
+// RefundExpense adds a refund of the amount for the expense with the ID, taking the category,
+// account and payee of the expense. [ErrRefundExceedsExpense] is returned if the refunds of the
+// expense would exceed its amount.
+func (s *ExpenseService) RefundExpense(ctx context.Context, id, amount int, description string) (*Expense, error) {
+	expenses, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(expenses, func(e Expense) bool { return e.ID == id })
+	if i == -1 {
+		return nil, fmt.Errorf("%w: %d", ErrExpenseNotFound, id)
+	}
+	original := expenses[i]
+	if !original.IsExpense() || original.IsRefund() {
+		return nil, errors.New("only expenses can be refunded, not income, transfers or refunds")
+	}
+
+	if refunded := RefundedAmount(expenses, id); refunded+amount > original.Amount {
+		return nil, fmt.Errorf("%w: %d of %d already refunded", ErrRefundExceedsExpense, refunded, original.Amount)
+	}
+
+	refund := Expense{
+		Date:        time.Now(),
+		Amount:      amount,
+		Category:    original.Category,
+		Description: description,
+		Account:     original.Account,
+		Payee:       original.Payee,
+		RefundOf:    id,
+	}
+
+	if refund.ID, err = s.expenseStorage.GenerateID(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.expenseStorage.Add(ctx, refund); err != nil {
+		return nil, err
+	}
+	if err := s.record(JournalEntry{Op: OpAdd, After: []Expense{refund}}); err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// DeleteExpense moves an expense to the trash by its ID
+func (s *ExpenseService) DeleteExpense(ctx context.Context, id int) error {
+	return s.DeleteExpenses(ctx, id)
+}
+
+// DeleteExpenses moves the expenses with the IDs to the trash at once.
+// The refunds of an expense must be deleted along with it, otherwise [ErrExpenseHasRefunds] is returned.
+func (s *ExpenseService) DeleteExpenses(ctx context.Context, ids ...int) error {
+	expenses, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkDeletedRefunds(expenses, ids); err != nil {
+		return err
+	}
+
+	var deleted []Expense
+	for _, expense := range expenses {
+		if slices.Contains(ids, expense.ID) {
+			deleted = append(deleted, expense)
+		}
+	}
+
+	if err := s.expenseStorage.Delete(ctx, ids...); err != nil {
+		return err
+	}
+	return s.record(JournalEntry{Op: OpDelete, Before: deleted})
+}
+
+// EditExpenses applies the options to the expenses and stores the ones which changed at once.
+// Nothing is stored if an edited expense is invalid, e.g. if its amount no longer matches its splits
+// or is below its refunds, which returns [ErrRefundExceedsExpense]. The category of a split expense
+// cannot be changed, as it would no longer match its splits, which returns [ErrSplitCategory].
+func (s *ExpenseService) EditExpenses(ctx context.Context, expenses []Expense, opts ...ExpenseOption) ([]Expense, error) {
+	var before, changed []Expense
+	for _, original := range expenses {
+		expense := original
+		expense.Tags = slices.Clone(expense.Tags)
+		for _, opt := range opts {
+			opt(&expense)
+		}
+
+		if err := ValidateAmount(expense.Amount); err != nil {
+			return nil, fmt.Errorf("expense %d: %w", expense.ID, err)
+		}
+		if len(expense.Splits) > 0 && expense.Category != original.Category {
+			return nil, fmt.Errorf("expense %d: %w", expense.ID, ErrSplitCategory)
+		}
+		if err := ValidateSplits(expense.Splits, expense.Amount); err != nil {
+			return nil, fmt.Errorf("expense %d: %w", expense.ID, err)
+		}
+		if err := ValidateShares(expense.PaidBy, expense.Shares, expense.Amount); err != nil {
+			return nil, fmt.Errorf("expense %d: %w", expense.ID, err)
+		}
+
+		if !expense.Equal(original) {
+			before = append(before, original)
+			changed = append(changed, expense)
+		}
+	}
+
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	stored, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, expense := range stored {
+		if j := slices.IndexFunc(changed, func(e Expense) bool { return e.ID == expense.ID }); j != -1 {
+			stored[i] = changed[j]
+		}
+	}
+	if err := checkRefunds(stored, changed); err != nil {
+		return nil, err
+	}
+
+	if err := s.expenseStorage.Update(ctx, changed...); err != nil {
+		return nil, err
+	}
+	if err := s.record(JournalEntry{Op: OpEdit, Before: before, After: changed}); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// TrashedExpenses lists the deleted expenses in the trash
+func (s *ExpenseService) TrashedExpenses(ctx context.Context) ([]TrashedExpense, error) {
+	return s.expenseStorage.Trash(ctx)
+}
+
+// RestoreExpense moves a deleted expense from the trash back among the expenses by its ID
+func (s *ExpenseService) RestoreExpense(ctx context.Context, id int) error {
+	if err := s.expenseStorage.Untrash(ctx, id); err != nil {
+		return err
+	}
+
+	if !s.recording() {
+		return nil
+	}
+	expenses, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return err
+	}
+	restored := slices.DeleteFunc(expenses, func(e Expense) bool { return e.ID != id })
+	return s.record(JournalEntry{Op: OpUntrash, After: restored})
+}
+
+// EmptyTrash permanently removes the expenses deleted longer than olderThan ago
+// and returns how many were removed. The removal is recorded in the audit log, not in the journal.
+func (s *ExpenseService) EmptyTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	before := time.Now().Add(-olderThan)
+
+	// The removed expenses are audited, so the log tells when an expense was gone for good
+	var removed []Expense
+	if s.auditLog != nil {
+		trash, err := s.expenseStorage.Trash(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, trashed := range trash {
+			if trashed.DeletedAt.Before(before) {
+				removed = append(removed, trashed.Expense)
+			}
+		}
+	}
+
+	n, err := s.expenseStorage.EmptyTrash(ctx, before)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	return n, s.audit(JournalEntry{Op: OpEmptyTrash, Before: removed})
 }
 
 // ListExpenses lists all expenses
-func (s *ExpenseService) ListExpenses() ([]Expense, error) {
-	expenses, err := s.expenseStorage.List()
+func (s *ExpenseService) ListExpenses(ctx context.Context) ([]Expense, error) {
+	expenses, err := s.expenseStorage.List(ctx)
 	if err != nil {
 		return []Expense{}, err
 	}
 	return expenses, nil
 }
 
-// ExpenseSummary calculates the total amount of all expenses
-func (s *ExpenseService) ExpenseSummary() (int, error) {
-	expenses, err := s.ListExpenses()
+// ListExpensesLenient lists the valid expenses and the malformed rows skipped, so a single bad row
+// does not hide all expenses. Storages which cannot skip rows list the expenses as [ExpenseService.ListExpenses].
+func (s *ExpenseService) ListExpensesLenient(ctx context.Context) ([]Expense, []Issue, error) {
+	lenient, ok := s.expenseStorage.(LenientStorage)
+	if !ok {
+		expenses, err := s.ListExpenses(ctx)
+		return expenses, nil, err
+	}
+	return lenient.ListLenient(ctx)
+}
+
+// ListExpensesAsOf lists the expenses as they were at the time. Storages keeping their own history
+// rebuild them, for the others the changes journaled since the time are reverted on the current expenses.
+// If neither the storage nor the journal keeps a history, [ErrAsOfUnsupported] is returned.
+func (s *ExpenseService) ListExpensesAsOf(ctx context.Context, t time.Time) ([]Expense, error) {
+	if historical, ok := s.expenseStorage.(HistoricalStorage); ok {
+		return historical.ListAsOf(ctx, t)
+	}
+	if s.journal == nil {
+		return nil, ErrAsOfUnsupported
+	}
+
+	entries, err := s.journal.Entries()
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenseStorage.List(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	return Rewind(expenses, entries, t), nil
+}
+
+// FormatStatus returns the format version the expenses are stored in and the migrations pending for it.
+// Storages without a versioned format are always in the current version.
+func (s *ExpenseService) FormatStatus(ctx context.Context) (int, []Migration, error) {
+	versioned, ok := s.expenseStorage.(VersionedStorage)
+	if !ok {
+		return FormatVersion, nil, nil
+	}
+
+	version, err := versioned.FormatVersion(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	pending, err := PendingMigrations(version)
+	return version, pending, err
+}
+
+// Migrate upgrades the stored expenses to the current format version and returns the applied
+// migrations and the backup taken before, see [VersionedStorage]. An upgrade is recorded in the audit log.
+func (s *ExpenseService) Migrate(ctx context.Context) ([]Migration, string, error) {
+	versioned, ok := s.expenseStorage.(VersionedStorage)
+	if !ok {
+		return nil, "", nil
+	}
+	applied, backup, err := versioned.Migrate(ctx)
+	if err != nil || len(applied) == 0 {
+		return applied, backup, err
+	}
+
+	note := fmt.Sprintf("upgraded to format version %d", applied[len(applied)-1].Version)
+	if backup != "" {
+		note += ", backup in " + backup
+	}
+	return applied, backup, s.audit(JournalEntry{Op: OpMigrate, Note: note})
+}
+
+// CheckStorage lists the integrity problems of the stored expenses.
+// If the storage cannot be checked, [ErrCheckUnsupported] is returned.
+func (s *ExpenseService) CheckStorage(ctx context.Context) (*CheckReport, error) {
+	checkable, ok := s.expenseStorage.(CheckableStorage)
+	if !ok {
+		return nil, ErrCheckUnsupported
+	}
+	return checkable.Check(ctx)
+}
+
+// RepairStorage quarantines the bad rows of the stored expenses and raises the ID counter above all IDs.
+// A repair is recorded in the audit log. If the storage cannot be repaired, [ErrCheckUnsupported] is returned.
+func (s *ExpenseService) RepairStorage(ctx context.Context) (*RepairReport, error) {
+	checkable, ok := s.expenseStorage.(CheckableStorage)
+	if !ok {
+		return nil, ErrCheckUnsupported
+	}
+	report, err := checkable.Repair(ctx)
+	if err != nil || (report.Quarantined == 0 && report.LastID == 0) {
+		return report, err
+	}
+
+	note := fmt.Sprintf("quarantined %d rows", report.Quarantined)
+	if report.File != "" {
+		note += " to " + report.File
+	}
+	if report.LastID != 0 {
+		note += fmt.Sprintf(", raised the ID counter to %d", report.LastID)
+	}
+	return report, s.audit(JournalEntry{Op: OpRepair, Note: note})
+}
+
+// ScanExpenses calls fn for each expense selected by the filter as it is read from the storage,
+// stopping at the first error returned by fn or when ctx is done
+func (s *ExpenseService) ScanExpenses(ctx context.Context, filter Filter, fn func(Expense) error) error {
+	return s.expenseStorage.Scan(ctx, filter, fn)
+}
 
+// ScanExpensesAsOf calls fn for each expense selected by the filter as it was at the time, see [ExpenseService.ListExpensesAsOf]
+func (s *ExpenseService) ScanExpensesAsOf(ctx context.Context, t time.Time, filter Filter, fn func(Expense) error) error {
+	expenses, err := s.ListExpensesAsOf(ctx, t)
+	if err != nil {
+		return err
+	}
+	return scanSlice(ctx, expenses, filter, fn)
+}
+
+// ExpenseSummary calculates the total amount of the expenses selected by the filter in a single pass,
+// income and transfers excluded
+func (s *ExpenseService) ExpenseSummary(ctx context.Context, filter Filter) (int, error) {
 	var total int
+	err := s.ScanExpenses(ctx, filter, func(expense Expense) error {
+		if expense.IsExpense() {
+			total += expense.NetAmount()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// RuleMatch is the rule matching an expense
+type RuleMatch struct {
+	Expense Expense // Expense as stored
+	Rule    *Rule   // First matching rule, nil if none matches
+}
+
+// MatchRules returns the rule matching each of the expenses
+func (s *ExpenseService) MatchRules(expenses []Expense) ([]RuleMatch, error) {
+	rules, err := s.rules()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]RuleMatch, len(expenses))
+	for i, expense := range expenses {
+		matches[i] = RuleMatch{Expense: expense, Rule: MatchRule(rules, expense)}
+	}
+	return matches, nil
+}
+
+// Recategorize applies the rules to the expenses and returns the ones which changed.
+// The changes are only stored if dryRun is false.
+func (s *ExpenseService) Recategorize(ctx context.Context, expenses []Expense, dryRun bool) ([]Expense, error) {
+	matches, err := s.MatchRules(expenses)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, changed []Expense
+	for _, match := range matches {
+		if match.Rule == nil {
+			continue
+		}
+
+		expense := match.Expense
+		expense.Tags = slices.Clone(expense.Tags)
+		match.Rule.Apply(&expense)
+		if !expense.Equal(match.Expense) {
+			before = append(before, match.Expense)
+			changed = append(changed, expense)
+		}
+	}
+
+	if dryRun || len(changed) == 0 {
+		return changed, nil
+	}
+
+	if err := s.expenseStorage.Update(ctx, changed...); err != nil {
+		return nil, err
+	}
+	if err := s.record(JournalEntry{Op: OpEdit, Before: before, After: changed}); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// Backup creates an archive of all expenses, the ID counter, the trash and the journal
+func (s *ExpenseService) Backup(ctx context.Context) (*Archive, error) {
+	lastID, err := s.expenseStorage.LastID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trash, err := s.expenseStorage.Trash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var journal []JournalEntry
+	if s.journal != nil {
+		if journal, err = s.journal.Entries(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Archive{
+		Format:    ArchiveFormat,
+		Version:   ArchiveVersion,
+		CreatedAt: time.Now(),
+		LastID:    lastID,
+		Expenses:  expenses,
+		Trash:     trash,
+		Journal:   journal,
+	}, nil
+}
+
+// Restore applies the archive to the storage according to the mode.
+// Replacing also replaces the trash and the journal, the replacement being recorded as the last change.
+// Merging keeps the current trash, journal and expenses, skips archived expenses identical to a current one and
+// assigns new IDs to the remaining archived expenses whose IDs are already taken, including by the trash.
+func (s *ExpenseService) Restore(ctx context.Context, archive *Archive, mode RestoreMode) error {
+	current, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	currentLastID, err := s.expenseStorage.LastID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if mode == RestoreReplace {
+		// The expenses are written first, a failure afterwards leaves them restored along with the current
+		// trash and journal, so the restore can be run again
+		if err := s.expenseStorage.Replace(ctx, archive.Expenses, archive.LastID); err != nil {
+			return err
+		}
+		// Archives before version 3 hold neither the trash nor the journal, the current ones are kept
+		if archive.Version >= 3 {
+			if err := s.expenseStorage.ReplaceTrash(ctx, archive.Trash); err != nil {
+				return err
+			}
+			if s.journal != nil {
+				if err := s.journal.Replace(archive.Journal); err != nil {
+					return err
+				}
+			}
+		}
+		return s.recordReplace(current, currentLastID, archive.Expenses, archive.LastID)
+	}
+
+	trash, err := s.expenseStorage.Trash(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]Expense, len(current))
+	for _, expense := range current {
+		byID[expense.ID] = expense
+	}
+	lastID := max(currentLastID, archive.LastID)
+	trashed := make(map[int]bool, len(trash))
+	for _, t := range trash {
+		trashed[t.ID] = true
+		lastID = max(lastID, t.ID)
+	}
+
+	merged := slices.Clone(current)
+	restored := len(merged)
+	renumbered := make(map[int]int)
+	for _, expense := range archive.Expenses {
+		existing, ok := byID[expense.ID]
+		if ok && existing.Equal(expense) {
+			continue
+		}
+		// The ID of a trashed expense stays taken, so the trashed expense can still be restored
+		if ok || trashed[expense.ID] {
+			lastID++
+			renumbered[expense.ID] = lastID
+			expense.ID = lastID
+		}
+		byID[expense.ID] = expense
+		merged = append(merged, expense)
+	}
+
+	// Refunds keep referring to their original expense when it is assigned a new ID
+	for i := restored; i < len(merged); i++ {
+		if id, ok := renumbered[merged[i].RefundOf]; ok {
+			merged[i].RefundOf = id
+		}
+	}
+
+	return s.replace(ctx, current, currentLastID, merged, lastID)
+}
+
+// replace replaces the current expenses and ID counter and records the replacement
+func (s *ExpenseService) replace(ctx context.Context, current []Expense, currentLastID int, expenses []Expense, lastID int) error {
+	if err := s.expenseStorage.Replace(ctx, expenses, lastID); err != nil {
+		return err
+	}
+	return s.recordReplace(current, currentLastID, expenses, lastID)
+}
+
+// recordReplace records the replacement of the current expenses and ID counter
+func (s *ExpenseService) recordReplace(current []Expense, currentLastID int, expenses []Expense, lastID int) error {
+	before, after := diffExpenses(current, expenses)
+	return s.record(JournalEntry{
+		Op:           OpReplace,
+		Before:       before,
+		After:        after,
+		LastIDBefore: currentLastID,
+		LastIDAfter:  lastID,
+	})
+}
+
+// SuggestCategories suggests up to n categories for an expense with the description and payee
+// learned from the existing expenses, see [SuggestCategories]
+func (s *ExpenseService) SuggestCategories(ctx context.Context, description, payee string, n int) ([]Suggestion, error) {
+	expenses, err := s.ListExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return SuggestCategories(expenses, description, payee, n), nil
+}
+
+// untrash restores the expenses from the trash, adding them again if the trash was emptied since
+func (s *ExpenseService) untrash(ctx context.Context, expenses []Expense) error {
 	for _, expense := range expenses {
-		total += expense.Amount
+		err := s.expenseStorage.Untrash(ctx, expense.ID)
+		if errors.Is(err, ErrExpenseNotFound) {
+			err = s.expenseStorage.Add(ctx, expense)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patch replaces the expenses by the current ones with the removed ones taken out and the added ones
+// put in, and resets the ID counter to lastID
+func (s *ExpenseService) patch(ctx context.Context, removed, added []Expense, lastID int) error {
+	current, err := s.expenseStorage.List(ctx)
+	if err != nil {
+		return err
+	}
+	return s.expenseStorage.Replace(ctx, patchExpenses(current, removed, added), lastID)
+}
+
+// revert applies the inverse of the mutation
+func (s *ExpenseService) revert(ctx context.Context, entry JournalEntry) error {
+	switch entry.Op {
+	case OpAdd, OpUntrash:
+		return s.expenseStorage.Delete(ctx, ids(entry.After)...)
+	case OpDelete:
+		return s.untrash(ctx, entry.Before)
+	case OpEdit:
+		return s.expenseStorage.Update(ctx, entry.Before...)
+	case OpReplace:
+		return s.patch(ctx, entry.After, entry.Before, entry.LastIDBefore)
+	}
+	return fmt.Errorf("cannot undo %s", entry.Op)
+}
+
+// apply applies the mutation again
+func (s *ExpenseService) apply(ctx context.Context, entry JournalEntry) error {
+	switch entry.Op {
+	case OpAdd, OpUntrash:
+		return s.untrash(ctx, entry.After)
+	case OpDelete:
+		return s.expenseStorage.Delete(ctx, ids(entry.Before)...)
+	case OpEdit:
+		return s.expenseStorage.Update(ctx, entry.After...)
+	case OpReplace:
+		return s.patch(ctx, entry.Before, entry.After, entry.LastIDAfter)
 	}
+	return fmt.Errorf("cannot redo %s", entry.Op)
+}
+
+// Undo reverts the last n mutations not undone yet, most recent first, and returns them.
+// Expenses added by an undone mutation are moved to the trash.
+func (s *ExpenseService) Undo(ctx context.Context, n int) ([]JournalEntry, error) {
+	return s.replay(ctx, n, OpUndo)
+}
+
+// Redo applies the last n undone mutations again, most recently undone first, and returns them
+func (s *ExpenseService) Redo(ctx context.Context, n int) ([]JournalEntry, error) {
+	return s.replay(ctx, n, OpRedo)
+}
 
-	return total, nil
+// replay undoes or redoes up to n mutations, recording each one in the journal
+func (s *ExpenseService) replay(ctx context.Context, n int, op Operation) ([]JournalEntry, error) {
+	nothing, replay := ErrNothingToUndo, s.revert
+	if op == OpRedo {
+		nothing, replay = ErrNothingToRedo, s.apply
+	}
+
+	if s.journal == nil {
+		return nil, nothing
+	}
+	entries, err := s.journal.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	undoable, redoable := History(entries)
+	stack := undoable
+	if op == OpRedo {
+		stack = redoable
+	}
+	if len(stack) == 0 {
+		return nil, nothing
+	}
+
+	var replayed []JournalEntry
+	for ; n > 0 && len(stack) > 0; n-- {
+		entry := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if err := replay(ctx, entry); err != nil {
+			return replayed, fmt.Errorf("%s of the %s: %w", op, entry.Describe(), err)
+		}
+		change := JournalEntry{Op: op, Ref: entry.Seq, Before: entry.After, After: entry.Before}
+		if op == OpRedo {
+			change.Before, change.After = entry.Before, entry.After
+		}
+		if err := s.record(change); err != nil {
+			return replayed, err
+		}
+		replayed = append(replayed, entry)
+	}
+	return replayed, nil
 }
@@ -1,74 +1,55 @@
 package expense
 
 import (
+	"context"
 	"errors"
-	"sync"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
-)
-
-type mockStorage struct {
-	id        int
-	idErr     error
-	addErr    error
-	deleteErr error
-	listErr   error
+	"time"
 
-	mu       sync.Mutex
-	expenses []Expense
-}
-
-func newMockStorage() *mockStorage {
-	return &mockStorage{}
-}
-
-func (m *mockStorage) GenerateID() (int, error) {
-	return m.id, m.idErr
-}
-
-func (m *mockStorage) Add(expense Expense) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.addErr != nil {
-		return m.addErr
-	}
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
-	m.expenses = append(m.expenses, expense)
-	return nil
+// newStorage returns an in-memory storage holding the expenses with the ID counter at lastID
+func newStorage(t *testing.T, lastID int, expenses ...Expense) *StorageMemory {
+	t.Helper()
+	s := NewStorageMemory()
+	require.NoError(t, s.Replace(t.Context(), expenses, lastID))
+	return s
 }
 
-func (m *mockStorage) Delete(id int) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	for i, e := range m.expenses {
-		if e.ID == id {
-			m.expenses = append(m.expenses[:i], m.expenses[i+1:]...)
-			return nil
-		}
-	}
-	return m.deleteErr
+// listed lists the expenses of the storage, failing the test on error
+func listed(t *testing.T, s ExpenseStorage) []Expense {
+	t.Helper()
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	return expenses
 }
 
-func (m *mockStorage) List() ([]Expense, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.listErr != nil {
-		return nil, m.listErr
-	}
-
-	return m.expenses, nil
+// lastID returns the ID counter of the storage, failing the test on error
+func lastID(t *testing.T, s ExpenseStorage) int {
+	t.Helper()
+	id, err := s.LastID(t.Context())
+	require.NoError(t, err)
+	return id
 }
 
 func TestExpenseService_AddExpense(t *testing.T) {
 	t.Run("fails with failed id generation", func(t *testing.T) {
-		s := newMockStorage()
-		s.idErr = errors.New("gen id err")
+		s := NewStorageMemory()
+		idErr := errors.New("gen id err")
+		s.FailOn(1, idErr)
 		service := ExpenseService{expenseStorage: s}
-		expense, err := service.AddExpense("category", "desc", 10)
+		expense, err := service.AddExpense(t.Context(), "category", "desc", 10)
 
 		if err == nil {
 			t.Error("expected error, got none")
 		}
-		if err != nil && !errors.Is(err, s.idErr) {
+		if err != nil && !errors.Is(err, idErr) {
 			t.Errorf("unexpected error: %v", err)
 		}
 
@@ -78,15 +59,16 @@ func TestExpenseService_AddExpense(t *testing.T) {
 	})
 
 	t.Run("fails with failed add", func(t *testing.T) {
-		s := newMockStorage()
-		s.addErr = errors.New("add err")
+		s := NewStorageMemory()
+		addErr := errors.New("add err")
+		s.FailOn(2, addErr) // The ID is generated first
 		service := ExpenseService{expenseStorage: s}
-		expense, err := service.AddExpense("category", "desc", 10)
+		expense, err := service.AddExpense(t.Context(), "category", "desc", 10)
 
 		if err == nil {
 			t.Error("expected error, got none")
 		}
-		if err != nil && !errors.Is(err, s.addErr) {
+		if err != nil && !errors.Is(err, addErr) {
 			t.Errorf("unexpected error: %v", err)
 		}
 
@@ -96,10 +78,9 @@ func TestExpenseService_AddExpense(t *testing.T) {
 	})
 
 	t.Run("successful add", func(t *testing.T) {
-		s := newMockStorage()
-		s.id = 3
+		s := newStorage(t, 2)
 		service := ExpenseService{expenseStorage: s}
-		expense, err := service.AddExpense("category", "desc", 10)
+		expense, err := service.AddExpense(t.Context(), "category", "desc", 10)
 
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -113,8 +94,8 @@ func TestExpenseService_AddExpense(t *testing.T) {
 			t.Errorf("expected expense category to be %s, got: %s", "category", expense.Category)
 		}
 
-		if expense.ID != s.id {
-			t.Errorf("expected expense ID to be %d, got: %d", s.id, expense.ID)
+		if expense.ID != 3 {
+			t.Errorf("expected expense ID to be %d, got: %d", 3, expense.ID)
 		}
 
 		if expense.Description != "desc" {
@@ -133,15 +114,16 @@ func TestExpenseService_AddExpense(t *testing.T) {
 
 func TestExpenseService_DeleteExpense(t *testing.T) {
 	t.Run("fails with storage err", func(t *testing.T) {
-		s := newMockStorage()
-		s.deleteErr = errors.New("delete err")
+		s := NewStorageMemory()
+		deleteErr := errors.New("delete err")
+		s.FailOn(2, deleteErr) // The expenses are listed first
 		service := ExpenseService{expenseStorage: s}
-		err := service.DeleteExpense(1)
+		err := service.DeleteExpense(t.Context(), 1)
 
 		if err == nil {
 			t.Error("expected error, got none")
 		}
-		if err != nil && !errors.Is(err, s.deleteErr) {
+		if err != nil && !errors.Is(err, deleteErr) {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
@@ -149,16 +131,17 @@ func TestExpenseService_DeleteExpense(t *testing.T) {
 
 func TestExpenseService_ListExpenses(t *testing.T) {
 	t.Run("fails with storage err", func(t *testing.T) {
-		s := newMockStorage()
-		s.listErr = errors.New("list err")
+		s := NewStorageMemory()
+		listErr := errors.New("list err")
+		s.FailOn(1, listErr)
 		service := ExpenseService{expenseStorage: s}
-		expenses, err := service.ListExpenses()
+		expenses, err := service.ListExpenses(t.Context())
 
 		if err == nil {
 			t.Error("expected error, got none")
 		}
 
-		if err != nil && !errors.Is(err, s.listErr) {
+		if err != nil && !errors.Is(err, listErr) {
 			t.Errorf("unexpected error: %v", err)
 		}
 
@@ -172,13 +155,13 @@ func TestExpenseService_ListExpenses(t *testing.T) {
 	})
 
 	t.Run("successfully returns expenses", func(t *testing.T) {
-		s := newMockStorage()
+		s := NewStorageMemory()
 
 		service := ExpenseService{expenseStorage: s}
-		expense1, _ := service.AddExpense("category", "expense 1", 10)
-		expense2, _ := service.AddExpense("category", "expense 2", 20)
+		expense1, _ := service.AddExpense(t.Context(), "category", "expense 1", 10)
+		expense2, _ := service.AddExpense(t.Context(), "category", "expense 2", 20)
 
-		expenses, err := service.ListExpenses()
+		expenses, err := service.ListExpenses(t.Context())
 
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -192,24 +175,24 @@ func TestExpenseService_ListExpenses(t *testing.T) {
 			t.Errorf("expected expenses to be not empty, got: %v", expenses)
 		}
 
-		if expenses[0] != *expense1 {
+		if !expenses[0].Equal(*expense1) {
 			t.Errorf("expected expense 1, got: %v", expenses[0])
 		}
 
-		if expenses[1] != *expense2 {
+		if !expenses[1].Equal(*expense2) {
 			t.Errorf("expected expense 2, got: %v", expenses[1])
 		}
 	})
 }
 
 func TestExpenseService_ExpenseSummary(t *testing.T) {
-	s := newMockStorage()
+	s := NewStorageMemory()
 
 	service := ExpenseService{expenseStorage: s}
-	service.AddExpense("category", "expense 1", 10)
-	service.AddExpense("category", "expense 2", 20)
+	service.AddExpense(t.Context(), "category", "expense 1", 10)
+	service.AddExpense(t.Context(), "category", "expense 2", 20)
 
-	total, err := service.ExpenseSummary()
+	total, err := service.ExpenseSummary(t.Context(), Filter{})
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -223,3 +206,540 @@ func TestExpenseService_ExpenseSummary(t *testing.T) {
 		t.Errorf("expected total to be 30, got: %v", total)
 	}
 }
+
+// failingReplace is a storage failing to replace the expenses
+type failingReplace struct {
+	*StorageMemory
+	err error
+}
+
+func (f failingReplace) Replace(context.Context, []Expense, int) error {
+	return f.err
+}
+
+func TestExpenseService_Restore(t *testing.T) {
+	date := time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)
+	current := []Expense{
+		{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: date},
+		{ID: 2, Amount: 20, Category: "Food", Description: "Dinner", Date: date},
+	}
+	archive := &Archive{
+		LastID: 3,
+		Expenses: []Expense{
+			{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: date},
+			{ID: 2, Amount: 5, Category: "Coffee", Description: "Latte", Date: date},
+			{ID: 3, Amount: 30, Category: "Travel", Description: "Taxi", Date: date},
+		},
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		s := newStorage(t, 2, current...)
+		service := ExpenseService{expenseStorage: s}
+
+		require.NoError(t, service.Restore(t.Context(), archive, RestoreReplace))
+		assert.Equal(t, archive.Expenses, listed(t, s))
+		assert.Equal(t, 3, lastID(t, s))
+	})
+
+	t.Run("replace journals only the changes", func(t *testing.T) {
+		s, journal := newStorage(t, 2, current...), &mockJournal{}
+		service := NewExpenseService(s, WithJournal(journal))
+
+		require.NoError(t, service.Restore(t.Context(), archive, RestoreReplace))
+		require.Len(t, journal.entries, 1)
+		assert.Equal(t, []Expense{current[1]}, journal.entries[0].Before)
+		assert.Equal(t, archive.Expenses[1:], journal.entries[0].After)
+
+		_, err := service.Undo(t.Context(), 1)
+		require.NoError(t, err)
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, current, expenses)
+
+		_, err = service.Redo(t.Context(), 1)
+		require.NoError(t, err)
+		expenses, err = s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, archive.Expenses, expenses)
+		lastID, err := s.LastID(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 3, lastID)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		s := newStorage(t, 2, current...)
+		service := ExpenseService{expenseStorage: s}
+
+		require.NoError(t, service.Restore(t.Context(), archive, RestoreMerge))
+
+		latte := archive.Expenses[1]
+		latte.ID = 4
+		assert.Equal(t, []Expense{current[0], current[1], latte, archive.Expenses[2]}, listed(t, s))
+		assert.Equal(t, 4, lastID(t, s))
+	})
+
+	t.Run("failed replace keeps the trash", func(t *testing.T) {
+		s := newStorage(t, 2, current...)
+		trash := []TrashedExpense{{Expense: Expense{ID: 9, Amount: 1, Category: "Food", Description: "Snack", Date: date}, DeletedAt: date}}
+		require.NoError(t, s.ReplaceTrash(t.Context(), trash))
+		replaceErr := errors.New("replace err")
+		service := ExpenseService{expenseStorage: failingReplace{s, replaceErr}}
+
+		withTrash := *archive
+		withTrash.Version = 3
+		assert.ErrorIs(t, service.Restore(t.Context(), &withTrash, RestoreReplace), replaceErr)
+
+		assert.Equal(t, current, listed(t, s))
+		kept, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, trash, kept, "nothing is restored once writing the expenses failed")
+	})
+
+	t.Run("merge keeps the IDs of the trash taken", func(t *testing.T) {
+		s := newStorage(t, 2, current[0])
+		require.NoError(t, s.Delete(t.Context(), 1))
+		service := ExpenseService{expenseStorage: s}
+
+		taxi := archive.Expenses[2]
+		taxi.ID = 1
+		require.NoError(t, service.Restore(t.Context(), &Archive{LastID: 1, Expenses: []Expense{taxi}}, RestoreMerge))
+
+		taxi.ID = 3
+		assert.Equal(t, []Expense{taxi}, listed(t, s))
+		require.NoError(t, service.RestoreExpense(t.Context(), 1))
+		assert.Equal(t, []int{1, 3}, ids(listed(t, s)))
+	})
+
+	t.Run("replace restores the trash and the journal", func(t *testing.T) {
+		source := NewExpenseService(NewStorageMemory(), WithJournal(&mockJournal{}))
+		for _, description := range []string{"Lunch", "Dinner"} {
+			_, err := source.AddExpense(t.Context(), "Food", description, 10)
+			require.NoError(t, err)
+		}
+		require.NoError(t, source.DeleteExpense(t.Context(), 1))
+		backup, err := source.Backup(t.Context())
+		require.NoError(t, err)
+
+		s, journal := NewStorageMemory(), &mockJournal{}
+		service := NewExpenseService(s, WithJournal(journal))
+		require.NoError(t, service.Restore(t.Context(), backup, RestoreReplace))
+
+		trash, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, backup.Trash, trash)
+		require.Len(t, journal.entries, len(backup.Journal)+1)
+		assert.Equal(t, OpReplace, journal.entries[len(backup.Journal)].Op, "the restore is recorded last")
+
+		// Undoing the restore empties the storage again, then the restored history continues with the delete
+		_, err = service.Undo(t.Context(), 2)
+		require.NoError(t, err)
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []int{1}, ids(expenses))
+	})
+
+	t.Run("merge keeps refunds with renumbered expenses", func(t *testing.T) {
+		s := newStorage(t, 2, current...)
+		service := ExpenseService{expenseStorage: s}
+
+		refunded := &Archive{
+			LastID: 3,
+			Expenses: []Expense{
+				{ID: 2, Amount: 5, Category: "Coffee", Description: "Latte", Date: date},
+				{ID: 3, Amount: 2, Category: "Coffee", Description: "Refund", Date: date, RefundOf: 2},
+			},
+		}
+		require.NoError(t, service.Restore(t.Context(), refunded, RestoreMerge))
+
+		expenses := listed(t, s)
+		require.Len(t, expenses, 4)
+		assert.Equal(t, 4, expenses[2].ID)
+		assert.Equal(t, 4, expenses[3].RefundOf)
+	})
+}
+
+type mockRules []Rule
+
+func (m mockRules) Rules() ([]Rule, error) {
+	return m, nil
+}
+
+func TestExpenseService_AddExpenseWithRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader("rules:\n  - name: coffee\n    match:\n      description: latte\n    set:\n      category: Coffee\n      tags: [caffeine]\n"))
+	require.NoError(t, err)
+
+	t.Run("categorizes by rule", func(t *testing.T) {
+		s := NewStorageMemory()
+		service := NewExpenseService(s, WithRules(mockRules(rules)))
+
+		expense, err := service.AddExpense(t.Context(), "", "Latte", 4, WithTags([]string{"work"}))
+		require.NoError(t, err)
+		assert.Equal(t, "Coffee", expense.Category)
+		assert.Equal(t, []string{"work", "caffeine"}, expense.Tags)
+	})
+
+	t.Run("given category wins", func(t *testing.T) {
+		s := NewStorageMemory()
+		service := NewExpenseService(s, WithRules(mockRules(rules)))
+
+		expense, err := service.AddExpense(t.Context(), "Treats", "Latte", 4)
+		require.NoError(t, err)
+		assert.Equal(t, "Treats", expense.Category)
+		assert.Empty(t, expense.Tags)
+	})
+
+	t.Run("fails without matching rule", func(t *testing.T) {
+		s := NewStorageMemory()
+		service := NewExpenseService(s, WithRules(mockRules(rules)))
+
+		expense, err := service.AddExpense(t.Context(), "", "Taxi", 30)
+		assert.ErrorIs(t, err, ErrNoCategory)
+		assert.Nil(t, expense)
+		assert.Empty(t, listed(t, s))
+	})
+}
+
+func TestExpenseService_Recategorize(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader("rules:\n  - name: coffee\n    match:\n      description: latte\n    set:\n      category: Coffee\n"))
+	require.NoError(t, err)
+
+	s := newStorage(t, 3,
+		Expense{ID: 1, Description: "Latte", Category: "Misc"},
+		Expense{ID: 2, Description: "Latte", Category: "Coffee"},
+		Expense{ID: 3, Description: "Taxi", Category: "Travel"},
+	)
+	service := NewExpenseService(s, WithRules(mockRules(rules)))
+
+	changed, err := service.Recategorize(t.Context(), listed(t, s), true)
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{{ID: 1, Description: "Latte", Category: "Coffee"}}, changed)
+	assert.Equal(t, "Misc", listed(t, s)[0].Category)
+
+	changed, err = service.Recategorize(t.Context(), listed(t, s), false)
+	require.NoError(t, err)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "Coffee", listed(t, s)[0].Category)
+}
+
+func TestExpenseService_AddSplitExpense(t *testing.T) {
+	splits := []Split{{Category: "Groceries", Amount: 15}, {Category: "Household", Amount: 40}}
+
+	t.Run("fails if splits do not sum to amount", func(t *testing.T) {
+		service := NewExpenseService(NewStorageMemory())
+		expense, err := service.AddExpense(t.Context(), "", "Supermarket", 50, WithSplits(splits))
+		assert.Error(t, err)
+		assert.Nil(t, expense)
+	})
+
+	t.Run("category of largest split", func(t *testing.T) {
+		service := NewExpenseService(NewStorageMemory())
+		expense, err := service.AddExpense(t.Context(), "", "Supermarket", 55, WithSplits(splits))
+		require.NoError(t, err)
+		assert.Equal(t, "Household", expense.Category)
+		assert.Equal(t, splits, expense.Splits)
+	})
+}
+
+func TestExpenseService_RefundExpense(t *testing.T) {
+	newService := func(refunds ...Expense) *ExpenseService {
+		expenses := []Expense{
+			{ID: 1, Amount: 100, Category: "Clothes", Description: "Shoes", Account: "Amex"},
+			{ID: 2, Amount: 3000, Category: "Salary", Description: "March", Kind: KindIncome},
+		}
+		return NewExpenseService(newStorage(t, 2+len(refunds), append(expenses, refunds...)...))
+	}
+
+	t.Run("fails for unknown expense", func(t *testing.T) {
+		service := newService()
+		_, err := service.RefundExpense(t.Context(), 9, 10, "Refund")
+		assert.ErrorIs(t, err, ErrExpenseNotFound)
+	})
+
+	t.Run("fails for income", func(t *testing.T) {
+		service := newService()
+		_, err := service.RefundExpense(t.Context(), 2, 10, "Refund")
+		assert.Error(t, err)
+	})
+
+	t.Run("takes category and account of the expense", func(t *testing.T) {
+		service := newService()
+		refund, err := service.RefundExpense(t.Context(), 1, 40, "Returned pair")
+		require.NoError(t, err)
+		assert.Equal(t, 3, refund.ID)
+		assert.Equal(t, 1, refund.RefundOf)
+		assert.Equal(t, "Clothes", refund.Category)
+		assert.Equal(t, "Amex", refund.Account)
+		expenses, err := service.ListExpenses(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, expenses, 3)
+
+		total, err := service.ExpenseSummary(t.Context(), Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, 60, total)
+	})
+
+	t.Run("fails if refunds exceed the amount", func(t *testing.T) {
+		service := newService(Expense{ID: 3, Amount: 70, Category: "Clothes", RefundOf: 1})
+
+		_, err := service.RefundExpense(t.Context(), 1, 31, "Refund")
+		assert.ErrorIs(t, err, ErrRefundExceedsExpense)
+
+		_, err = service.RefundExpense(t.Context(), 1, 30, "Refund")
+		assert.NoError(t, err)
+	})
+
+	t.Run("edits cannot exceed the amount", func(t *testing.T) {
+		service := newService(Expense{ID: 3, Amount: 70, Category: "Clothes", RefundOf: 1})
+		expenses, err := service.ListExpenses(t.Context())
+		require.NoError(t, err)
+		original, refund := expenses[0], expenses[2]
+
+		_, err = service.EditExpenses(t.Context(), []Expense{refund}, WithAmount(101))
+		assert.ErrorIs(t, err, ErrRefundExceedsExpense, "a refund above its expense")
+		_, err = service.EditExpenses(t.Context(), []Expense{original}, WithAmount(69))
+		assert.ErrorIs(t, err, ErrRefundExceedsExpense, "an expense below its refunds")
+
+		_, err = service.EditExpenses(t.Context(), []Expense{original}, WithAmount(70))
+		assert.NoError(t, err)
+		_, err = service.EditExpenses(t.Context(), []Expense{original, refund}, WithAmount(50))
+		assert.NoError(t, err, "the expense and its refund are checked together")
+	})
+
+	t.Run("refunds are deleted along with the expense", func(t *testing.T) {
+		service := newService(Expense{ID: 3, Amount: 70, Category: "Clothes", RefundOf: 1})
+
+		assert.ErrorIs(t, service.DeleteExpense(t.Context(), 1), ErrExpenseHasRefunds)
+		require.NoError(t, service.DeleteExpense(t.Context(), 3))
+		require.NoError(t, service.DeleteExpense(t.Context(), 1))
+
+		service = newService(Expense{ID: 3, Amount: 70, Category: "Clothes", RefundOf: 1})
+		assert.NoError(t, service.DeleteExpenses(t.Context(), 1, 3))
+	})
+}
+
+func TestExpenseService_EditExpenses(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 10, Category: "Misc", Description: "Ride", Tags: []string{"uber"}},
+		{ID: 2, Amount: 20, Category: "Transport", Description: "Taxi", Tags: []string{"work"}},
+		{ID: 3, Amount: 30, Category: "Food", Description: "Market", Splits: []Split{{"Food", 20, ""}, {"Household", 10, ""}}},
+	}
+
+	t.Run("stores only changed expenses", func(t *testing.T) {
+		s := newStorage(t, 3, slices.Clone(expenses)...)
+		service := NewExpenseService(s)
+
+		changed, err := service.EditExpenses(t.Context(), expenses[:2], WithCategory("Transport"), WithAddedTags([]string{"work"}), WithoutTags([]string{"uber"}))
+		require.NoError(t, err)
+		require.Len(t, changed, 1)
+		assert.Equal(t, Expense{ID: 1, Amount: 10, Category: "Transport", Description: "Ride", Tags: []string{"work"}}, changed[0])
+		assert.Equal(t, changed[0], listed(t, s)[0])
+		assert.Equal(t, []string{"uber"}, expenses[0].Tags)
+	})
+
+	t.Run("fails if an edited expense is invalid", func(t *testing.T) {
+		s := newStorage(t, 3, slices.Clone(expenses)...)
+		service := NewExpenseService(s)
+
+		_, err := service.EditExpenses(t.Context(), expenses, WithAmount(25))
+		assert.Error(t, err)
+		assert.Equal(t, expenses, listed(t, s))
+
+		_, err = service.EditExpenses(t.Context(), expenses, WithCategory("Misc"))
+		assert.ErrorIs(t, err, ErrSplitCategory, "the category of a split expense must match its splits")
+		assert.Equal(t, expenses, listed(t, s))
+	})
+}
+
+func TestExpenseService_UndoRedo(t *testing.T) {
+	s := NewStorageMemory()
+	journal := &mockJournal{}
+	service := NewExpenseService(s, WithJournal(journal))
+
+	_, err := service.Undo(t.Context(), 1)
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+
+	added, err := service.AddExpense(t.Context(), "Food", "Lunch", 10)
+	require.NoError(t, err)
+	_, err = service.EditExpenses(t.Context(), []Expense{*added}, WithAmount(12))
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteExpense(t.Context(), 1))
+	assert.Empty(t, listed(t, s))
+
+	undone, err := service.Undo(t.Context(), 2)
+	require.NoError(t, err)
+	require.Len(t, undone, 2)
+	assert.Equal(t, OpDelete, undone[0].Op)
+	assert.Equal(t, OpEdit, undone[1].Op)
+	expenses := listed(t, s)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, 10, expenses[0].Amount)
+
+	redone, err := service.Redo(t.Context(), 5)
+	require.NoError(t, err)
+	assert.Len(t, redone, 2)
+	assert.Empty(t, listed(t, s))
+
+	_, err = service.Redo(t.Context(), 1)
+	assert.ErrorIs(t, err, ErrNothingToRedo)
+}
+
+func TestExpenseService_AuditLog(t *testing.T) {
+	s := NewStorageMemory()
+	log := NewAuditLogFS(t.TempDir(), Actor{User: "alice"})
+	service := NewExpenseService(s, WithJournal(&mockJournal{}), WithAuditLog(log))
+
+	_, err := service.AddExpense(t.Context(), "Food", "Lunch", 10)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteExpense(t.Context(), 1))
+	_, err = service.Undo(t.Context(), 1)
+	require.NoError(t, err)
+
+	entries, err := log.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []Operation{OpAdd, OpDelete, OpUndo}, []Operation{entries[0].Op, entries[1].Op, entries[2].Op})
+	assert.Equal(t, "alice", entries[2].User)
+	assert.True(t, entries[2].Affects(1))
+	assert.NoError(t, VerifyAudit(entries))
+}
+
+func TestExpenseService_AuditMaintenance(t *testing.T) {
+	dir := t.TempDir()
+	content := "#expense-tracker format 2\n1,10,Food,Lunch,2025-04-20\n2,20,Food,Dinner,2025-13-21\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(content), 0644))
+	log := NewAuditLogFS(dir, Actor{User: "alice"})
+	service := NewExpenseService(NewStorageFS(dir), WithJournal(NewJournalFS(dir)), WithAuditLog(log))
+
+	_, err := service.RepairStorage(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteExpense(t.Context(), 1))
+	removed, err := service.EmptyTrash(t.Context(), -time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	entries, err := log.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, OpRepair, entries[0].Op)
+	assert.Contains(t, entries[0].Note, "quarantined 1 rows")
+	assert.Equal(t, OpEmptyTrash, entries[2].Op)
+	assert.True(t, entries[2].Affects(1), "the removed expenses are recorded")
+
+	journal, err := NewJournalFS(dir).Entries()
+	require.NoError(t, err)
+	assert.Len(t, journal, 1, "only the delete can be undone")
+
+	t.Run("migrate", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte("1,10,Food,Lunch,2025-04-20,{}\n"), 0644))
+		log := NewAuditLogFS(dir, Actor{User: "alice"})
+		service := NewExpenseService(NewStorageFS(dir), WithAuditLog(log))
+
+		_, _, err := service.Migrate(t.Context())
+		require.NoError(t, err)
+		entries, err := log.Entries()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, OpMigrate, entries[0].Op)
+		assert.Contains(t, entries[0].Note, "format version 2")
+	})
+}
+
+func TestExpenseService_ListExpensesAsOf(t *testing.T) {
+	_, err := NewExpenseService(NewStorageMemory()).ListExpensesAsOf(t.Context(), time.Now())
+	assert.ErrorIs(t, err, ErrAsOfUnsupported)
+
+	t.Run("journal", func(t *testing.T) {
+		s := NewStorageMemory()
+		service := NewExpenseService(s, WithJournal(NewJournalFS(t.TempDir())))
+		_, err := service.AddExpense(t.Context(), "Food", "Lunch", 10)
+		require.NoError(t, err)
+		added := time.Now()
+		require.NoError(t, service.DeleteExpense(t.Context(), 1))
+
+		expenses, err := service.ListExpensesAsOf(t.Context(), added)
+		require.NoError(t, err)
+		require.Len(t, expenses, 1)
+		assert.Equal(t, "Lunch", expenses[0].Description)
+
+		expenses, err = service.ListExpensesAsOf(t.Context(), time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, expenses)
+	})
+
+	s := NewStorageEvents(t.TempDir())
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10}))
+	expenses, err := NewExpenseService(s).ListExpensesAsOf(t.Context(), time.Now())
+	require.NoError(t, err)
+	assert.Len(t, expenses, 1)
+}
+
+func TestExpenseService_ListExpensesLenient(t *testing.T) {
+	s := newStorage(t, 1, Expense{ID: 1, Amount: 10})
+
+	expenses, skipped, err := NewExpenseService(s).ListExpensesLenient(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, expenses, 1)
+	assert.Empty(t, skipped)
+}
+
+func TestExpenseService_ScanExpensesAsOf(t *testing.T) {
+	s := NewStorageEvents(t.TempDir())
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10, Category: "Food"}))
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 2, Amount: 20, Category: "Fun"}))
+	at := time.Now()
+	require.NoError(t, s.Delete(t.Context(), 1))
+
+	var scanned []int
+	err := NewExpenseService(s).ScanExpensesAsOf(t.Context(), at, Filter{Category: "Food"}, func(expense Expense) error {
+		scanned = append(scanned, expense.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, scanned)
+}
+
+func TestExpenseService_StorageFaults(t *testing.T) {
+	s := NewStorageMemory()
+	journal := &mockJournal{}
+	service := NewExpenseService(s, WithJournal(journal))
+	fault := errors.New("disk full")
+
+	t.Run("failed add is not journaled", func(t *testing.T) {
+		s.FailOn(2, fault) // The ID is generated first
+		_, err := service.AddExpense(t.Context(), "Food", "Lunch", 10)
+		assert.ErrorIs(t, err, fault)
+
+		entries, err := journal.Entries()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("undo stops at the failing revert", func(t *testing.T) {
+		for _, description := range []string{"Lunch", "Dinner"} {
+			_, err := service.AddExpense(t.Context(), "Food", description, 10)
+			require.NoError(t, err)
+		}
+
+		s.FailOn(2, fault)
+		undone, err := service.Undo(t.Context(), 2)
+		assert.ErrorIs(t, err, fault)
+		require.Len(t, undone, 1)
+		assert.Equal(t, "Dinner", undone[0].After[0].Description)
+
+		expenses, err := service.ListExpenses(t.Context())
+		require.NoError(t, err)
+		require.Len(t, expenses, 1)
+		assert.Equal(t, "Lunch", expenses[0].Description)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		s.SetLatency(time.Hour)
+		defer s.SetLatency(0)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := service.ListExpenses(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,301 @@
+package expense
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Share is the part of a shared expense a person owes
+type Share struct {
+	Person string `json:"person"` // Name of the person in the people registry
+	Amount int    `json:"amount"` // Amount the person owes in dollars
+}
+
+// ShareMode is how a shared expense is divided between its participants
+type ShareMode string
+
+const (
+	ShareEqual   ShareMode = "equal"   // Equal parts, values are ignored
+	SharePercent ShareMode = "percent" // Percentages summing to 100
+	ShareExact   ShareMode = "exact"   // Exact amounts summing to the amount
+)
+
+// ParseShareMode parses a share mode and returns an error if it's invalid
+func ParseShareMode(mode string) (ShareMode, error) {
+	m := ShareMode(strings.ToLower(strings.TrimSpace(mode)))
+	if m != ShareEqual && m != SharePercent && m != ShareExact {
+		return "", fmt.Errorf("invalid share mode %q: must be one of equal, percent, exact", mode)
+	}
+	return m, nil
+}
+
+// Participant is a person sharing an expense with the value of their share,
+// a percentage or an amount depending on the share mode
+type Participant struct {
+	Person string
+	Value  int
+}
+
+// ParseParticipant parses a participant in the form name or name=value
+// and returns an error if it's invalid
+func ParseParticipant(participant string) (Participant, error) {
+	name, value, hasValue := strings.Cut(participant, "=")
+
+	name, err := ParsePersonName(name)
+	if err != nil {
+		return Participant{}, fmt.Errorf("invalid participant %q: %w", participant, err)
+	}
+	if !hasValue {
+		return Participant{Person: name}, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return Participant{}, fmt.Errorf("invalid participant %q: share must be a non-negative integer", participant)
+	}
+	return Participant{Person: name, Value: n}, nil
+}
+
+// ComputeShares divides the amount between the participants according to the mode.
+// Amounts which cannot be divided evenly are given a dollar each to the first participants when splitting equally,
+// and to the participants with the largest fractional parts when splitting by percentage, never to a 0% participant.
+func ComputeShares(amount int, mode ShareMode, participants []Participant) ([]Share, error) {
+	if len(participants) == 0 {
+		return nil, errors.New("invalid shares: must have at least one participant")
+	}
+
+	for i, participant := range participants {
+		for _, other := range participants[:i] {
+			if strings.EqualFold(participant.Person, other.Person) {
+				return nil, fmt.Errorf("invalid shares: %s participates more than once", participant.Person)
+			}
+		}
+	}
+
+	shares := make([]Share, len(participants))
+	switch mode {
+	case ShareEqual:
+		for i, participant := range participants {
+			shares[i] = Share{Person: participant.Person, Amount: amount / len(participants)}
+		}
+	case SharePercent:
+		var percent int
+		for i, participant := range participants {
+			percent += participant.Value
+			shares[i] = Share{Person: participant.Person, Amount: amount * participant.Value / 100}
+		}
+		if percent != 100 {
+			return nil, fmt.Errorf("invalid shares: percentages sum to %d instead of 100", percent)
+		}
+
+		// Largest remainder method, the remainder is less than the number of non-zero percentages
+		order := make([]int, 0, len(participants))
+		for i, participant := range participants {
+			if participant.Value > 0 {
+				order = append(order, i)
+			}
+		}
+		fraction := func(i int) int { return amount * participants[i].Value % 100 }
+		slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(fraction(b), fraction(a)) })
+		for _, i := range order[:amount-SharesAmount(shares)] {
+			shares[i].Amount++
+		}
+	case ShareExact:
+		for i, participant := range participants {
+			shares[i] = Share{Person: participant.Person, Amount: participant.Value}
+		}
+		if sum := SharesAmount(shares); sum != amount {
+			return nil, fmt.Errorf("invalid shares: shares sum to %d but the amount is %d", sum, amount)
+		}
+	default:
+		return nil, fmt.Errorf("invalid share mode %q", mode)
+	}
+
+	for i := 0; SharesAmount(shares) < amount; i++ {
+		shares[i%len(shares)].Amount++
+	}
+	return shares, nil
+}
+
+// ValidateShares validates that a shared expense has a payer and shares summing to its amount
+func ValidateShares(paidBy string, shares []Share, amount int) error {
+	if len(shares) == 0 {
+		if paidBy != "" {
+			return errors.New("invalid shares: a payer requires participants")
+		}
+		return nil
+	}
+
+	if paidBy == "" {
+		return errors.New("invalid shares: must have a payer")
+	}
+	if sum := SharesAmount(shares); sum != amount {
+		return fmt.Errorf("invalid shares: shares sum to %d but the amount is %d", sum, amount)
+	}
+	return nil
+}
+
+// SharesAmount returns the sum of the amounts of the shares
+func SharesAmount(shares []Share) int {
+	var sum int
+	for _, share := range shares {
+		sum += share.Amount
+	}
+	return sum
+}
+
+// Balance is the net position of a person across shared expenses and settlements.
+// A positive net means the person is owed money, a negative one that they owe money.
+type Balance struct {
+	Person string `json:"person"`
+	Net    int    `json:"net"`
+}
+
+// Balances computes the net balance of each person from the shared expenses and settlements.
+// People are compared case-insensitively, settled up people are left out.
+func Balances(expenses []Expense, settlements []Settlement) []Balance {
+	var (
+		nets  = make(map[string]int)
+		names = make(map[string]string)
+	)
+	add := func(person string, amount int) {
+		key := strings.ToLower(person)
+		names[key] = person
+		nets[key] += amount
+	}
+
+	for _, expense := range expenses {
+		if len(expense.Shares) == 0 {
+			continue
+		}
+		add(expense.PaidBy, expense.Amount)
+		for _, share := range expense.Shares {
+			add(share.Person, -share.Amount)
+		}
+	}
+	for _, settlement := range settlements {
+		add(settlement.From, settlement.Amount)
+		add(settlement.To, -settlement.Amount)
+	}
+
+	balances := make([]Balance, 0, len(nets))
+	for key, net := range nets {
+		if net != 0 {
+			balances = append(balances, Balance{Person: names[key], Net: net})
+		}
+	}
+	slices.SortFunc(balances, func(a, b Balance) int {
+		return cmp.Or(cmp.Compare(b.Net, a.Net), cmp.Compare(a.Person, b.Person))
+	})
+	return balances
+}
+
+// Transfer is a payment settling a debt between two people
+type Transfer struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+// maxMinimalSettleUp is the largest number of people SettleUp finds the fewest transfers for,
+// the search grows exponentially with it
+const maxMinimalSettleUp = 16
+
+// SettleUp proposes the fewest transfers settling the balances. People are split into the most groups
+// whose balances sum to zero, each group of k people is then settled with k-1 transfers, the largest
+// debtor repeatedly paying the largest creditor. With more than 16 people the groups are not searched
+// and at most n-1 transfers are proposed.
+func SettleUp(balances []Balance) []Transfer {
+	var unsettled []Balance
+	for _, balance := range balances {
+		if balance.Net != 0 {
+			unsettled = append(unsettled, balance)
+		}
+	}
+	if len(unsettled) > maxMinimalSettleUp {
+		return settleGroup(unsettled)
+	}
+
+	// most[mask] is the most zero-sum groups the people in mask can be split into
+	n := len(unsettled)
+	sums := make([]int, 1<<n)
+	most := make([]int, 1<<n)
+	for mask := 1; mask < 1<<n; mask++ {
+		low := bits.TrailingZeros(uint(mask))
+		sums[mask] = sums[mask&(mask-1)] + unsettled[low].Net
+		for i := range n {
+			if mask&(1<<i) != 0 {
+				most[mask] = max(most[mask], most[mask&^(1<<i)])
+			}
+		}
+		if sums[mask] == 0 {
+			most[mask]++
+		}
+	}
+
+	// Remove people one at a time keeping the most groups, every zero-sum mask passed closes a group
+	var transfers []Transfer
+	var group []Balance
+	for mask := 1<<n - 1; mask != 0; {
+		i := 0
+		for mask&(1<<i) == 0 || most[mask&^(1<<i)]+boolInt(sums[mask] == 0) != most[mask] {
+			i++
+		}
+		group = append(group, unsettled[i])
+		mask &^= 1 << i
+		if sums[mask] == 0 {
+			transfers = append(transfers, settleGroup(group)...)
+			group = nil
+		}
+	}
+	slices.SortStableFunc(transfers, func(a, b Transfer) int { return cmp.Compare(b.Amount, a.Amount) })
+	return transfers
+}
+
+// boolInt returns 1 for true and 0 for false
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// settleGroup settles balances summing to zero with at most n-1 transfers. The largest debtor
+// repeatedly pays the largest creditor, so every transfer settles at least one person.
+func settleGroup(balances []Balance) []Transfer {
+	var creditors, debtors []Balance
+	for _, balance := range balances {
+		if balance.Net > 0 {
+			creditors = append(creditors, balance)
+		} else if balance.Net < 0 {
+			debtors = append(debtors, Balance{Person: balance.Person, Net: -balance.Net})
+		}
+	}
+	largest := func(a, b Balance) int {
+		return cmp.Or(cmp.Compare(b.Net, a.Net), cmp.Compare(a.Person, b.Person))
+	}
+
+	var transfers []Transfer
+	for len(creditors) > 0 && len(debtors) > 0 {
+		slices.SortFunc(creditors, largest)
+		slices.SortFunc(debtors, largest)
+
+		amount := min(creditors[0].Net, debtors[0].Net)
+		transfers = append(transfers, Transfer{From: debtors[0].Person, To: creditors[0].Person, Amount: amount})
+
+		creditors[0].Net -= amount
+		debtors[0].Net -= amount
+		if creditors[0].Net == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].Net == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,130 @@
+package expense
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParticipant(t *testing.T) {
+	t.Run("parses without value", func(t *testing.T) {
+		participant, err := ParseParticipant(" Alice ")
+		require.NoError(t, err)
+		assert.Equal(t, Participant{Person: "Alice"}, participant)
+	})
+	t.Run("parses with value", func(t *testing.T) {
+		participant, err := ParseParticipant("Bob=30")
+		require.NoError(t, err)
+		assert.Equal(t, Participant{Person: "Bob", Value: 30}, participant)
+	})
+	t.Run("fails with invalid value", func(t *testing.T) {
+		_, err := ParseParticipant("Bob=-1")
+		assert.Error(t, err)
+	})
+	t.Run("fails with empty name", func(t *testing.T) {
+		_, err := ParseParticipant("=10")
+		assert.Error(t, err)
+	})
+}
+
+func TestComputeShares(t *testing.T) {
+	participants := []Participant{{Person: "Alice", Value: 50}, {Person: "Bob", Value: 30}, {Person: "Carol", Value: 20}}
+
+	t.Run("equal gives remainder to first participants", func(t *testing.T) {
+		shares, err := ComputeShares(62, ShareEqual, participants)
+		require.NoError(t, err)
+		assert.Equal(t, []Share{{"Alice", 21}, {"Bob", 21}, {"Carol", 20}}, shares)
+	})
+	t.Run("percent", func(t *testing.T) {
+		shares, err := ComputeShares(101, SharePercent, participants)
+		require.NoError(t, err)
+		assert.Equal(t, []Share{{"Alice", 51}, {"Bob", 30}, {"Carol", 20}}, shares)
+	})
+	t.Run("percent gives remainder to largest fractions", func(t *testing.T) {
+		shares, err := ComputeShares(10, SharePercent, []Participant{{Person: "Alice", Value: 0}, {Person: "Bob", Value: 33}, {Person: "Carol", Value: 67}})
+		require.NoError(t, err)
+		assert.Equal(t, []Share{{"Alice", 0}, {"Bob", 3}, {"Carol", 7}}, shares)
+	})
+	t.Run("fails if percentages do not sum to 100", func(t *testing.T) {
+		_, err := ComputeShares(100, SharePercent, participants[:2])
+		assert.Error(t, err)
+	})
+	t.Run("exact", func(t *testing.T) {
+		shares, err := ComputeShares(100, ShareExact, participants)
+		require.NoError(t, err)
+		assert.Equal(t, []Share{{"Alice", 50}, {"Bob", 30}, {"Carol", 20}}, shares)
+	})
+	t.Run("fails if exact amounts do not sum to amount", func(t *testing.T) {
+		_, err := ComputeShares(90, ShareExact, participants)
+		assert.Error(t, err)
+	})
+	t.Run("fails with duplicate participant", func(t *testing.T) {
+		_, err := ComputeShares(10, ShareEqual, []Participant{{Person: "Alice"}, {Person: "alice"}})
+		assert.Error(t, err)
+	})
+	t.Run("fails without participants", func(t *testing.T) {
+		_, err := ComputeShares(10, ShareEqual, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateShares(t *testing.T) {
+	shares := []Share{{"Alice", 10}, {"Bob", 10}}
+
+	assert.NoError(t, ValidateShares("", nil, 20))
+	assert.NoError(t, ValidateShares("Alice", shares, 20))
+	assert.Error(t, ValidateShares("Alice", nil, 20))
+	assert.Error(t, ValidateShares("", shares, 20))
+	assert.Error(t, ValidateShares("Alice", shares, 30))
+}
+
+func TestBalancesAndSettleUp(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 60, PaidBy: "Alice", Shares: []Share{{"Alice", 20}, {"Bob", 20}, {"Carol", 20}}},
+		{ID: 2, Amount: 30, PaidBy: "Bob", Shares: []Share{{"Bob", 15}, {"carol", 15}}},
+		{ID: 3, Amount: 99},
+	}
+	settlements := []Settlement{{From: "Carol", To: "Alice", Amount: 5}}
+
+	balances := Balances(expenses, settlements)
+	assert.Equal(t, []Balance{{"Alice", 35}, {"Bob", -5}, {"Carol", -30}}, balances)
+
+	assert.Equal(t, []Transfer{
+		{From: "Carol", To: "Alice", Amount: 30},
+		{From: "Bob", To: "Alice", Amount: 5},
+	}, SettleUp(balances))
+
+	settlements = append(settlements, Settlement{From: "Carol", To: "Alice", Amount: 30}, Settlement{From: "Bob", To: "Alice", Amount: 5})
+	assert.Empty(t, Balances(expenses, settlements))
+	assert.Empty(t, SettleUp(nil))
+}
+
+func TestSettleUp_Fewest(t *testing.T) {
+	// Paying the largest creditor first needs 4 transfers, settling Bob with Carol apart needs 3
+	balances := []Balance{{"Alice", 4}, {"Bob", 3}, {"Dave", -2}, {"Erin", -2}, {"Carol", -3}}
+	assert.Equal(t, []Transfer{
+		{From: "Carol", To: "Bob", Amount: 3},
+		{From: "Dave", To: "Alice", Amount: 2},
+		{From: "Erin", To: "Alice", Amount: 2},
+	}, SettleUp(balances))
+
+	t.Run("many people settle with at most n-1 transfers", func(t *testing.T) {
+		var balances []Balance
+		for i := range maxMinimalSettleUp + 2 {
+			balances = append(balances, Balance{Person: fmt.Sprint("Person", i), Net: 1 - 2*(i%2)})
+		}
+		transfers := SettleUp(balances)
+		assert.LessOrEqual(t, len(transfers), len(balances)-1)
+
+		nets := make(map[string]int)
+		for _, transfer := range transfers {
+			nets[transfer.From] -= transfer.Amount
+			nets[transfer.To] += transfer.Amount
+		}
+		for _, balance := range balances {
+			assert.Equal(t, balance.Net, nets[balance.Person], balance.Person)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrSplitCategory is returned when the category of a split expense is changed without its splits
+var ErrSplitCategory = errors.New("the categories of a split expense are set by its splits")
+
+// Split is a part of an expense attributed to its own category
+type Split struct {
+	Category string `json:"category"`       // Category of the part
+	Amount   int    `json:"amount"`         // Amount of the part in dollars
+	Note     string `json:"note,omitempty"` // Optional note on the part
+}
+
+// ParseSplit parses a split in the form category=amount or category=amount:note
+// and returns an error if it's invalid
+func ParseSplit(split string) (Split, error) {
+	category, rest, ok := strings.Cut(split, "=")
+	if !ok {
+		return Split{}, fmt.Errorf("invalid expense split %q: must be in the form category=amount[:note]", split)
+	}
+
+	category, err := ParseCategory(category)
+	if err != nil {
+		return Split{}, fmt.Errorf("invalid expense split %q: %w", split, err)
+	}
+
+	amountText, note, _ := strings.Cut(rest, ":")
+	amount, err := strconv.Atoi(strings.TrimSpace(amountText))
+	if err != nil {
+		return Split{}, fmt.Errorf("invalid expense split %q: amount is not an integer", split)
+	}
+	if err := ValidateAmount(amount); err != nil {
+		return Split{}, fmt.Errorf("invalid expense split %q: %w", split, err)
+	}
+
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > 255 {
+		return Split{}, fmt.Errorf("invalid expense split %q: note must not exceed 255 characters", split)
+	}
+
+	return Split{Category: category, Amount: amount, Note: note}, nil
+}
+
+// ValidateSplits validates that an expense is split into at least two parts summing to its amount
+func ValidateSplits(splits []Split, amount int) error {
+	if len(splits) == 1 {
+		return errors.New("invalid expense splits: must have at least two splits")
+	}
+
+	if sum := SplitsAmount(splits); len(splits) > 0 && sum != amount {
+		return fmt.Errorf("invalid expense splits: splits sum to %d but the amount is %d", sum, amount)
+	}
+
+	return nil
+}
+
+// SplitsAmount returns the sum of the amounts of the splits
+func SplitsAmount(splits []Split) int {
+	var sum int
+	for _, split := range splits {
+		sum += split.Amount
+	}
+	return sum
+}
+
+// largestSplit returns the split with the largest amount, the first one on ties
+func largestSplit(splits []Split) Split {
+	largest := splits[0]
+	for _, split := range splits[1:] {
+		if split.Amount > largest.Amount {
+			largest = split
+		}
+	}
+	return largest
+}
@@ -0,0 +1,54 @@
+package expense
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSplit(t *testing.T) {
+	t.Run("fails without separator", func(t *testing.T) {
+		_, err := ParseSplit("Groceries")
+		assert.Error(t, err)
+	})
+	t.Run("fails with empty category", func(t *testing.T) {
+		_, err := ParseSplit(" =40")
+		assert.Error(t, err)
+	})
+	t.Run("fails with invalid amount", func(t *testing.T) {
+		_, err := ParseSplit("Groceries=forty")
+		assert.Error(t, err)
+	})
+	t.Run("fails with non-positive amount", func(t *testing.T) {
+		_, err := ParseSplit("Groceries=0")
+		assert.Error(t, err)
+	})
+	t.Run("parses without note", func(t *testing.T) {
+		split, err := ParseSplit(" Groceries = 40 ")
+		require.NoError(t, err)
+		assert.Equal(t, Split{Category: "Groceries", Amount: 40}, split)
+	})
+	t.Run("parses with note", func(t *testing.T) {
+		split, err := ParseSplit("Household=15: soap: and sponges")
+		require.NoError(t, err)
+		assert.Equal(t, Split{Category: "Household", Amount: 15, Note: "soap: and sponges"}, split)
+	})
+}
+
+func TestValidateSplits(t *testing.T) {
+	splits := []Split{{Category: "Groceries", Amount: 40}, {Category: "Household", Amount: 15}}
+
+	t.Run("no splits", func(t *testing.T) {
+		assert.NoError(t, ValidateSplits(nil, 10))
+	})
+	t.Run("fails with single split", func(t *testing.T) {
+		assert.Error(t, ValidateSplits(splits[:1], 40))
+	})
+	t.Run("fails if sum differs from amount", func(t *testing.T) {
+		assert.Error(t, ValidateSplits(splits, 50))
+	})
+	t.Run("validates if sum equals amount", func(t *testing.T) {
+		assert.NoError(t, ValidateSplits(splits, 55))
+	})
+}
@@ -1,9 +1,53 @@
 package expense
 
+import (
+	"context"
+	"time"
+)
+
 // ExpenseStorage interface defines the methods for managing expenses.
 type ExpenseStorage interface {
-	GenerateID() (int, error)  // Generates a unique ID for a new expense.
-	Add(expense Expense) error // Adds a new expense to the storage.
-	Delete(id int) error       // Deletes an expense from the storage.
-	List() ([]Expense, error)  // Lists all expenses in the storage.
+	GenerateID(ctx context.Context) (int, error)           // Generates a unique ID for a new expense.
+	LastID(ctx context.Context) (int, error)               // Returns the last generated ID, 0 if none was generated.
+	Add(ctx context.Context, expense Expense) error        // Adds a new expense to the storage.
+	Delete(ctx context.Context, ids ...int) error          // Moves expenses from the storage to the trash.
+	Update(ctx context.Context, expenses ...Expense) error // Updates existing expenses in the storage.
+	List(ctx context.Context) ([]Expense, error)           // Lists all expenses in the storage.
+
+	// Scan calls fn for each expense selected by the filter in the order of List, stopping at the
+	// first error returned by fn or when ctx is done. Storages read the expenses one at a time if they can.
+	Scan(ctx context.Context, filter Filter, fn func(Expense) error) error
+
+	Trash(ctx context.Context) ([]TrashedExpense, error)            // Lists the deleted expenses in the trash.
+	Untrash(ctx context.Context, id int) error                      // Moves a deleted expense from the trash back to the storage.
+	EmptyTrash(ctx context.Context, before time.Time) (int, error)  // Permanently removes the expenses deleted before the time.
+	ReplaceTrash(ctx context.Context, trash []TrashedExpense) error // Replaces the deleted expenses in the trash.
+
+	// Replace atomically replaces all expenses in the storage and resets the ID counter to lastID.
+	Replace(ctx context.Context, expenses []Expense, lastID int) error
+}
+
+// ImportStorage copies the expenses, the ID counter and the trash of from into to, replacing
+// everything to holds, and returns how many expenses were imported
+func ImportStorage(ctx context.Context, from, to ExpenseStorage) (int, error) {
+	expenses, err := from.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	lastID, err := from.LastID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	trash, err := from.Trash(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := to.Replace(ctx, expenses, lastID); err != nil {
+		return 0, err
+	}
+	if err := to.ReplaceTrash(ctx, trash); err != nil {
+		return 0, err
+	}
+	return len(expenses), nil
 }
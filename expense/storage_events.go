@@ -0,0 +1,461 @@
+package expense
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrAsOfUnsupported is returned when the storage keeps no history to rebuild earlier states from
+var ErrAsOfUnsupported = errors.New("storage keeps no history of the expenses")
+
+// HistoricalStorage is implemented by storages able to rebuild the expenses as they were at a time
+type HistoricalStorage interface {
+	ListAsOf(ctx context.Context, t time.Time) ([]Expense, error) // Lists the expenses as they were at the time.
+}
+
+// EventType is the kind of an event in the event stream
+type EventType string
+
+const (
+	EventIDGenerated      EventType = "id_generated"      // An ID was generated for a new expense
+	EventExpenseAdded     EventType = "expense_added"     // An expense was added
+	EventExpenseDeleted   EventType = "expense_deleted"   // Expenses were moved to the trash
+	EventExpenseUpdated   EventType = "expense_updated"   // Expenses were changed
+	EventExpenseRestored  EventType = "expense_restored"  // An expense was moved from the trash back
+	EventTrashEmptied     EventType = "trash_emptied"     // The expenses deleted before a time were removed from the trash
+	EventExpensesReplaced EventType = "expenses_replaced" // All expenses were replaced and the ID counter reset
+	EventTrashReplaced    EventType = "trash_replaced"    // All deleted expenses in the trash were replaced
+)
+
+// Event is a change of the expenses in the event stream
+type Event struct {
+	Seq      int       `json:"seq"`                // Position of the event in the stream, starting from 1
+	Time     time.Time `json:"time"`               // Time the event was appended
+	Type     EventType `json:"type"`               // Kind of the event
+	Expenses []Expense `json:"expenses,omitempty"` // Added, updated or replacing expenses
+	IDs      []int     `json:"ids,omitempty"`      // Deleted or restored expenses
+	LastID   int       `json:"last_id,omitempty"`  // Generated ID, or the ID counter after a replacement
+	Before   time.Time `json:"before,omitzero"`    // Time before which deleted expenses were removed from the trash
+
+	Trash []TrashedExpense `json:"trash,omitempty"` // Deleted expenses replacing the trash
+}
+
+// eventState is the state of the storage after replaying the events up to Seq.
+// It is also the format of the snapshot, which records where the replay continues in the stream.
+type eventState struct {
+	Seq      int              `json:"seq"`      // Last applied event
+	Time     time.Time        `json:"time"`     // Time of the last applied event
+	Offset   int64            `json:"offset"`   // Byte offset of the next event in the stream
+	LastID   int              `json:"last_id"`  // ID counter
+	Expenses []Expense        `json:"expenses"` // Expenses ordered as they were added
+	Trash    []TrashedExpense `json:"trash"`    // Deleted expenses in the order they were deleted
+
+	snapshotSeq int // Last event included in the snapshot on disk
+}
+
+// index returns the position of the expense with the ID, -1 if there is none
+func (st *eventState) index(id int) int {
+	return slices.IndexFunc(st.Expenses, func(e Expense) bool { return e.ID == id })
+}
+
+// apply applies the event to the state, the event is validated before it is appended
+func (st *eventState) apply(event Event) {
+	switch event.Type {
+	case EventIDGenerated:
+		st.LastID = event.LastID
+	case EventExpenseAdded:
+		st.Expenses = append(st.Expenses, event.Expenses...)
+	case EventExpenseDeleted:
+		for _, id := range event.IDs {
+			if i := st.index(id); i != -1 {
+				st.Trash = append(st.Trash, TrashedExpense{Expense: st.Expenses[i], DeletedAt: event.Time})
+				st.Expenses = slices.Delete(st.Expenses, i, i+1)
+			}
+		}
+	case EventExpenseUpdated:
+		for _, expense := range event.Expenses {
+			if i := st.index(expense.ID); i != -1 {
+				st.Expenses[i] = expense
+			}
+		}
+	case EventExpenseRestored:
+		for _, id := range event.IDs {
+			// The latest deletion wins should the trash hold the ID more than once
+			var restored *Expense
+			for i := len(st.Trash) - 1; i >= 0 && restored == nil; i-- {
+				if st.Trash[i].ID == id {
+					restored = &st.Trash[i].Expense
+				}
+			}
+			if restored == nil {
+				continue
+			}
+
+			// Keep the expenses ordered by ID
+			at := slices.IndexFunc(st.Expenses, func(e Expense) bool { return e.ID > id })
+			if at == -1 {
+				at = len(st.Expenses)
+			}
+			st.Expenses = slices.Insert(st.Expenses, at, *restored)
+			st.Trash = slices.DeleteFunc(st.Trash, func(t TrashedExpense) bool { return t.ID == id })
+		}
+	case EventTrashEmptied:
+		st.Trash = slices.DeleteFunc(st.Trash, func(t TrashedExpense) bool { return t.DeletedAt.Before(event.Before) })
+	case EventExpensesReplaced:
+		st.Expenses = append([]Expense{}, event.Expenses...)
+		st.LastID = event.LastID
+	case EventTrashReplaced:
+		st.Trash = append([]TrashedExpense{}, event.Trash...)
+	}
+
+	st.Seq = event.Seq
+	st.Time = event.Time
+}
+
+// StorageEvents stores the expenses as an append-only stream of events and rebuilds the
+// expenses by replaying them. A snapshot of the state is taken every snapshotInterval events,
+// so only the events after it are replayed.
+type StorageEvents struct {
+	eventsfile       string
+	snapshotfile     string
+	snapshotInterval int
+}
+
+// NewStorageEvents creates a new StorageEvents keeping the events and the snapshot in dirname.
+// It initializes the storage directory, panics if it fails due to an error other than file already exists.
+func NewStorageEvents(dirname string) *StorageEvents {
+	if err := os.MkdirAll(dirname, os.ModePerm); err != nil && !os.IsExist(err) {
+		panic(err)
+	}
+
+	return &StorageEvents{
+		eventsfile:       filepath.Join(dirname, "events.jsonl"),
+		snapshotfile:     filepath.Join(dirname, "snapshot.json"),
+		snapshotInterval: 100,
+	}
+}
+
+// snapshot reads the snapshot, a missing snapshot results in the empty state
+func (s *StorageEvents) snapshot() (*eventState, error) {
+	state := &eventState{Expenses: []Expense{}, Trash: []TrashedExpense{}}
+
+	data, err := os.ReadFile(s.snapshotfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	state.snapshotSeq = state.Seq
+	return state, nil
+}
+
+// replay applies the events of the stream following the state, up to the events appended
+// at until or all of them if until is zero
+func (s *StorageEvents) replay(ctx context.Context, state *eventState, until time.Time) error {
+	file, err := os.Open(s.eventsfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(state.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A line without newline is an append interrupted midway, it is ignored and overwritten by the next one
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("invalid event at offset %d: %w", state.Offset, err)
+		}
+		if !until.IsZero() && event.Time.After(until) {
+			return nil
+		}
+
+		state.apply(event)
+		state.Offset += int64(len(line))
+	}
+}
+
+// load rebuilds the current state from the snapshot and the events following it
+func (s *StorageEvents) load(ctx context.Context) (*eventState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	state, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.replay(ctx, state, time.Time{}); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// writeSnapshot replaces the snapshot with the state.
+// The snapshot is written to a temporary file first which is then renamed over the existing one,
+// so a failure midway leaves the previous snapshot intact.
+func (s *StorageEvents) writeSnapshot(state *eventState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshotfile), "snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.snapshotfile); err != nil {
+		return err
+	}
+	state.snapshotSeq = state.Seq
+	return nil
+}
+
+// append appends the event to the stream and applies it to the state,
+// taking a snapshot once enough events followed the previous one
+func (s *StorageEvents) append(state *eventState, event Event) error {
+	event.Seq = state.Seq + 1
+	event.Time = time.Now()
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	file, err := os.OpenFile(s.eventsfile, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// The state ends at the last complete event, dropping the rest of an append interrupted midway
+	if err := file.Truncate(state.Offset); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(data, state.Offset); err != nil {
+		return err
+	}
+
+	state.apply(event)
+	state.Offset += int64(len(data))
+
+	if state.Seq-state.snapshotSeq >= s.snapshotInterval {
+		return s.writeSnapshot(state)
+	}
+	return nil
+}
+
+// LastID returns the last generated ID, or 0 if no ID was generated yet.
+func (s *StorageEvents) LastID(ctx context.Context) (int, error) {
+	state, err := s.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return state.LastID, nil
+}
+
+// GenerateID generates a new unique ID for an expense starting from 1
+func (s *StorageEvents) GenerateID(ctx context.Context) (int, error) {
+	state, err := s.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	id := state.LastID + 1
+	if err := s.append(state, Event{Type: EventIDGenerated, LastID: id}); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Add appends an event adding the expense
+func (s *StorageEvents) Add(ctx context.Context, expense Expense) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	return s.append(state, Event{Type: EventExpenseAdded, Expenses: []Expense{expense}})
+}
+
+// Delete appends an event moving the expenses to the trash.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is deleted.
+func (s *StorageEvents) Delete(ctx context.Context, ids ...int) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	for _, id := range ids {
+		if state.index(id) == -1 {
+			return ErrExpenseNotFound
+		}
+	}
+
+	return s.append(state, Event{Type: EventExpenseDeleted, IDs: ids})
+}
+
+// Update appends an event replacing the expenses having the same IDs.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is updated.
+func (s *StorageEvents) Update(ctx context.Context, expenses ...Expense) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, expense := range expenses {
+		if state.index(expense.ID) == -1 {
+			return ErrExpenseNotFound
+		}
+	}
+
+	return s.append(state, Event{Type: EventExpenseUpdated, Expenses: expenses})
+}
+
+// List rebuilds the current expenses
+func (s *StorageEvents) List(ctx context.Context) ([]Expense, error) {
+	state, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return state.Expenses, nil
+}
+
+// Scan rebuilds the current expenses and calls fn for each one selected by the filter
+func (s *StorageEvents) Scan(ctx context.Context, filter Filter, fn func(Expense) error) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	return scanSlice(ctx, state.Expenses, filter, fn)
+}
+
+// ListAsOf rebuilds the expenses as they were at the time by replaying the events appended until then.
+// The snapshot is only used if it was taken at or before the time, otherwise the stream is replayed from the start.
+func (s *StorageEvents) ListAsOf(ctx context.Context, t time.Time) ([]Expense, error) {
+	state, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if state.Time.After(t) {
+		state = &eventState{Expenses: []Expense{}, Trash: []TrashedExpense{}}
+	}
+
+	if err := s.replay(ctx, state, t); err != nil {
+		return nil, err
+	}
+	return state.Expenses, nil
+}
+
+// Trash lists the deleted expenses in the order they were deleted
+func (s *StorageEvents) Trash(ctx context.Context) ([]TrashedExpense, error) {
+	state, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return state.Trash, nil
+}
+
+// Untrash appends an event moving the deleted expense with the ID from the trash back among the expenses.
+// If the expense is not in the trash, [ErrExpenseNotFound] is returned.
+func (s *StorageEvents) Untrash(ctx context.Context, id int) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(state.Trash, func(t TrashedExpense) bool { return t.ID == id }) {
+		return ErrExpenseNotFound
+	}
+	if state.index(id) != -1 {
+		return ErrExpenseExists
+	}
+
+	return s.append(state, Event{Type: EventExpenseRestored, IDs: []int{id}})
+}
+
+// EmptyTrash appends an event permanently removing the expenses deleted before the time
+// and returns how many were removed. The removed expenses remain in the event stream.
+func (s *StorageEvents) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	state, err := s.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, trashed := range state.Trash {
+		if trashed.DeletedAt.Before(before) {
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	return removed, s.append(state, Event{Type: EventTrashEmptied, Before: before})
+}
+
+// Replace appends an event replacing all expenses and resetting the ID counter to lastID.
+func (s *StorageEvents) Replace(ctx context.Context, expenses []Expense, lastID int) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	return s.append(state, Event{Type: EventExpensesReplaced, Expenses: expenses, LastID: lastID})
+}
+
+// ReplaceTrash appends an event replacing the deleted expenses in the trash
+func (s *StorageEvents) ReplaceTrash(ctx context.Context, trash []TrashedExpense) error {
+	state, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	return s.append(state, Event{Type: EventTrashReplaced, Trash: trash})
+}
+
+var (
+	_ ExpenseStorage    = (*StorageEvents)(nil)
+	_ HistoricalStorage = (*StorageEvents)(nil)
+)
@@ -0,0 +1,152 @@
+package expense
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEvents(t *testing.T) {
+	s := NewStorageEvents(t.TempDir())
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, expenses)
+
+	for i := 1; i <= 3; i++ {
+		id, err := s.GenerateID(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, i, id)
+		require.NoError(t, s.Add(t.Context(), Expense{ID: id, Amount: i * 10, Category: "Food"}))
+	}
+
+	require.NoError(t, s.Update(t.Context(), Expense{ID: 2, Amount: 25, Category: "Fun"}))
+	assert.ErrorIs(t, s.Update(t.Context(), Expense{ID: 4}), ErrExpenseNotFound)
+
+	require.NoError(t, s.Delete(t.Context(), 1, 3))
+	assert.ErrorIs(t, s.Delete(t.Context(), 1), ErrExpenseNotFound)
+
+	expenses, err = s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{{ID: 2, Amount: 25, Category: "Fun"}}, expenses)
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	assert.Equal(t, 1, trash[0].ID)
+
+	t.Run("untrash keeps expenses ordered by ID", func(t *testing.T) {
+		require.NoError(t, s.Untrash(t.Context(), 1))
+		assert.ErrorIs(t, s.Untrash(t.Context(), 1), ErrExpenseNotFound)
+
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, ids(expenses))
+	})
+
+	t.Run("empty trash", func(t *testing.T) {
+		removed, err := s.EmptyTrash(t.Context(), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		trash, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		require.NoError(t, s.Replace(t.Context(), []Expense{{ID: 7, Amount: 70}}, 9))
+
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []int{7}, ids(expenses))
+
+		lastID, err := s.LastID(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 9, lastID)
+	})
+}
+
+func TestStorageEvents_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStorageEvents(dir)
+	s.snapshotInterval = 4
+
+	for i := 1; i <= 5; i++ {
+		id, err := s.GenerateID(t.Context())
+		require.NoError(t, err)
+		require.NoError(t, s.Add(t.Context(), Expense{ID: id, Amount: i}))
+	}
+
+	snapshot, err := s.snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, 8, snapshot.Seq)
+	assert.Len(t, snapshot.Expenses, 4)
+
+	// A new storage continues from the snapshot with the events following it
+	expenses, err := NewStorageEvents(dir).List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids(expenses))
+
+	t.Run("interrupted append is dropped", func(t *testing.T) {
+		file, err := os.OpenFile(filepath.Join(dir, "events.jsonl"), os.O_WRONLY|os.O_APPEND, 0644)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"seq":11,"type":"expense_a`)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, expenses, 5)
+
+		require.NoError(t, s.Delete(t.Context(), 5))
+		expenses, err = s.List(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, expenses, 4)
+	})
+}
+
+func TestStorageEvents_ListAsOf(t *testing.T) {
+	s := NewStorageEvents(t.TempDir())
+	s.snapshotInterval = 2
+
+	before := time.Now()
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10}))
+	first := time.Now()
+	require.NoError(t, s.Update(t.Context(), Expense{ID: 1, Amount: 12}))
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 2, Amount: 20}))
+	second := time.Now()
+	require.NoError(t, s.Delete(t.Context(), 1))
+
+	expenses, err := s.ListAsOf(t.Context(), before)
+	require.NoError(t, err)
+	assert.Empty(t, expenses)
+
+	// The snapshot was taken after the time, so the stream is replayed from the start
+	expenses, err = s.ListAsOf(t.Context(), first)
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{{ID: 1, Amount: 10}}, expenses)
+
+	expenses, err = s.ListAsOf(t.Context(), second)
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{{ID: 1, Amount: 12}, {ID: 2, Amount: 20}}, expenses)
+
+	expenses, err = s.ListAsOf(t.Context(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{{ID: 2, Amount: 20}}, expenses)
+}
+
+func TestStorageEvents_Cancelled(t *testing.T) {
+	s := NewStorageEvents(t.TempDir())
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := s.List(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
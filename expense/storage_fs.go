@@ -1,19 +1,24 @@
 package expense
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // StorageFS represents a file system-based storage for expenses.
 type StorageFS struct {
 	idsfile      string
 	expensesfile string
+	trashfile    string
 }
 
 // NewStorageFS creates a new StorageFS instance.
@@ -26,25 +31,33 @@ func NewStorageFS(dirname string) *StorageFS {
 	return &StorageFS{
 		idsfile:      filepath.Join(dirname, "ids.txt"),
 		expensesfile: filepath.Join(dirname, "expenses.txt"),
+		trashfile:    filepath.Join(dirname, "trash.txt"),
 	}
 }
 
-// GenerateID generates a new unique ID for an expense starting from 1
-// Creates a new file if it doesn't exist, otherwise truncates and writes the new ID.
-func (s *StorageFS) GenerateID() (int, error) {
+// LastID returns the last generated ID, or 0 if no ID was generated yet.
+func (s *StorageFS) LastID(ctx context.Context) (int, error) {
 	data, err := os.ReadFile(s.idsfile)
 	// Only return error if it's not a file not found error
 	if err != nil && !os.IsNotExist(err) {
 		return 0, err
 	}
 
-	var lastID int
-	if len(data) > 0 {
-		id, err := strconv.Atoi(string(data))
-		if err != nil {
-			return 0, err
-		}
-		lastID = id
+	// The counter may be edited by hand and end with a newline
+	counter := strings.TrimSpace(string(data))
+	if counter == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(counter)
+}
+
+// GenerateID generates a new unique ID for an expense starting from 1
+// Creates a new file if it doesn't exist, otherwise truncates and writes the new ID.
+func (s *StorageFS) GenerateID(ctx context.Context) (int, error) {
+	lastID, err := s.LastID(ctx)
+	if err != nil {
+		return 0, err
 	}
 
 	newID := lastID + 1
@@ -66,49 +79,52 @@ func (s *StorageFS) add(expense Expense, w io.Writer) error {
 	return writer.Error()
 }
 
-type MyReader struct {
-	*strings.Reader
-	original string
-}
-
-func NewMyReader(s string) *MyReader {
-	return &MyReader{
-		Reader:   strings.NewReader(s),
-		original: s,
-	}
-}
-func (m *MyReader) Truncate(size int64) error {
-	if size > m.Size() {
-		return errors.New("size is large")
+// Add appends the expense to the file, starting a new file with the format header
+func (s *StorageFS) Add(ctx context.Context, expense Expense) error {
+	if err := s.checkFormat(ctx); err != nil {
+		return err
 	}
-	m.Reset(m.original[0:size])
-	return nil
-}
 
-func (s *StorageFS) Add(expense Expense) error {
 	file, err := os.OpenFile(s.expensesfile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0655)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if _, err := io.WriteString(file, formatHeaderLine()); err != nil {
+			return err
+		}
+	}
+
 	return s.add(expense, file)
 }
 
 var (
 	ErrExpenseNotFound = errors.New("expense not found")
+	ErrExpenseExists   = errors.New("expense already exists")
 )
 
-type ReadWriteSeekTruncater interface {
-	io.ReadWriteSeeker
-	Truncate(size int64) error
+// newReader creates a CSV reader allowing records with and without the extension column
+// and skipping the format header
+func newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	return reader
 }
 
-func (s *StorageFS) delete(id int, rw ReadWriteSeekTruncater) error {
-	reader := csv.NewReader(rw)
+// delete reads the records and splits off the expenses with the IDs, returning the records kept and the deleted expenses.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned.
+func (s *StorageFS) delete(ids []int, r io.Reader) ([][]string, []Expense, error) {
+	reader := newReader(r)
 	var (
-		records         [][]string
-		expenseToDelete *Expense
+		records [][]string
+		deleted []Expense
 	)
 	for {
 		record, err := reader.Read()
@@ -116,57 +132,73 @@ func (s *StorageFS) delete(id int, rw ReadWriteSeekTruncater) error {
 			if err == io.EOF {
 				break
 			}
-			return err
+			return nil, nil, err
 		}
 
 		expense, err := decode(record)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-		if expense.ID == id {
-			expenseToDelete = expense
+		if slices.Contains(ids, expense.ID) {
+			deleted = append(deleted, *expense)
 		} else {
 			records = append(records, record)
 		}
 	}
 
-	if expenseToDelete == nil {
-		return ErrExpenseNotFound
+	if len(deleted) != len(ids) {
+		return nil, nil, ErrExpenseNotFound
 	}
+	return records, deleted, nil
+}
 
-	if _, err := rw.Seek(0, io.SeekStart); err != nil {
+// Delete moves the expenses from the file to the trash.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is deleted.
+func (s *StorageFS) Delete(ctx context.Context, ids ...int) error {
+	if err := s.checkFormat(ctx); err != nil {
 		return err
 	}
 
-	if err := rw.Truncate(0); err != nil {
+	data, err := os.ReadFile(s.expensesfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrExpenseNotFound
+		}
 		return err
 	}
 
-	// Return early without seeking and writing if there are no records left
-	if len(records) == 0 {
-		return nil
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	records, deleted, err := s.delete(ids, bytes.NewReader(data))
+	if err != nil {
+		return err
 	}
 
-	return csv.NewWriter(rw).WriteAll(records)
-}
-
-// Delete deletes an expense from the file.
-// If the file does not exist, [ErrExpenseNotFound] is returned.
-func (s *StorageFS) Delete(id int) error {
-	file, err := os.OpenFile(s.expensesfile, os.O_RDWR, 0655)
+	previous, err := s.Trash(ctx)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return ErrExpenseNotFound
-		}
 		return err
 	}
-	defer file.Close()
+	trash := slices.Clone(previous)
+	now := time.Now()
+	for _, expense := range deleted {
+		trash = append(trash, TrashedExpense{Expense: expense, DeletedAt: now})
+	}
 
-	return s.delete(id, file)
+	// The expenses are trashed first, so a failure while rewriting the file cannot lose them
+	if err := s.replaceTrash(trash); err != nil {
+		return err
+	}
+	if err := s.writeRecords(records); err != nil {
+		// Take them out of the trash again, so they are not both kept and trashed
+		if rollbackErr := s.replaceTrash(previous); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *StorageFS) list(r io.Reader) ([]Expense, error) {
-	reader := csv.NewReader(r)
+func (s *StorageFS) list(ctx context.Context, r io.Reader) ([]Expense, error) {
+	reader := newReader(r)
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, err
@@ -174,6 +206,10 @@ func (s *StorageFS) list(r io.Reader) ([]Expense, error) {
 
 	expenses := make([]Expense, len(records))
 	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		expense, err := decode(record)
 		if err != nil {
 			return nil, err
@@ -184,7 +220,13 @@ func (s *StorageFS) list(r io.Reader) ([]Expense, error) {
 	return expenses, nil
 }
 
-func (s *StorageFS) List() ([]Expense, error) {
+// List lists the expenses in the file.
+// A file of an earlier format version is upgraded first, for a newer one [ErrFormatTooNew] is returned.
+func (s *StorageFS) List(ctx context.Context) ([]Expense, error) {
+	if err := s.checkFormat(ctx); err != nil {
+		return nil, err
+	}
+
 	file, err := os.Open(s.expensesfile)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -194,7 +236,137 @@ func (s *StorageFS) List() ([]Expense, error) {
 	}
 	defer file.Close()
 
-	return s.list(file)
+	return s.list(ctx, file)
+}
+
+// Scan reads the expenses one at a time and calls fn for each one selected by the filter,
+// so only a single expense is held in memory
+func (s *StorageFS) Scan(ctx context.Context, filter Filter, fn func(Expense) error) error {
+	if err := s.checkFormat(ctx); err != nil {
+		return err
+	}
+
+	file, err := os.Open(s.expensesfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	reader := newReader(file)
+	reader.ReuseRecord = true
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		expense, err := decode(record)
+		if err != nil {
+			return err
+		}
+		if !filter.Match(*expense) {
+			continue
+		}
+		if err := fn(*expense); err != nil {
+			return err
+		}
+	}
+}
+
+// writeAll replaces all expenses in the file
+func (s *StorageFS) writeAll(expenses []Expense) error {
+	records := make([][]string, len(expenses))
+	for i, expense := range expenses {
+		records[i] = encode(expense)
+	}
+	return s.writeRecords(records)
+}
+
+// writeRecords replaces the content of the file with the format header followed by the records
+func (s *StorageFS) writeRecords(records [][]string) error {
+	var buf bytes.Buffer
+	buf.WriteString(formatHeaderLine())
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	return s.writeRaw(buf.Bytes())
+}
+
+// writeRaw replaces the content of the file with the data, see [writeFileAtomic]
+func (s *StorageFS) writeRaw(data []byte) error {
+	return writeFileAtomic(s.expensesfile, data)
+}
+
+// writeFileAtomic replaces the content of the file with the data.
+// The data is written to a temporary file first which is then renamed over the existing one,
+// so a failure midway leaves the previous content intact.
+func writeFileAtomic(path string, data []byte) error {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Replace replaces all expenses in the file and resets the ID counter to lastID.
+func (s *StorageFS) Replace(ctx context.Context, expenses []Expense, lastID int) error {
+	if err := s.checkFormat(ctx); err != nil {
+		return err
+	}
+
+	if err := s.writeAll(expenses); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.idsfile, []byte(strconv.Itoa(lastID)), os.ModePerm)
+}
+
+// Update replaces the expenses having the same IDs in one rewrite of the file.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is updated.
+func (s *StorageFS) Update(ctx context.Context, expenses ...Expense) error {
+	current, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[int]Expense, len(expenses))
+	for _, expense := range expenses {
+		updates[expense.ID] = expense
+	}
+
+	for i, expense := range current {
+		if update, ok := updates[expense.ID]; ok {
+			current[i] = update
+			delete(updates, expense.ID)
+		}
+	}
+
+	if len(updates) > 0 {
+		return ErrExpenseNotFound
+	}
+
+	return s.writeAll(current)
 }
 
 var _ ExpenseStorage = (*StorageFS)(nil)
@@ -2,10 +2,13 @@ package expense
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -15,18 +18,18 @@ import (
 func TestStorageFS_GenerateID(t *testing.T) {
 	t.Run("first id is 1", func(t *testing.T) {
 		s := NewStorageFS(t.TempDir())
-		id, err := s.GenerateID()
+		id, err := s.GenerateID(t.Context())
 		assert.NoError(t, err)
 		assert.Equal(t, 1, id)
 	})
 
 	t.Run("sequential ids", func(t *testing.T) {
 		s := NewStorageFS(t.TempDir())
-		id1, err := s.GenerateID()
+		id1, err := s.GenerateID(t.Context())
 		assert.NoError(t, err)
 		assert.Equal(t, 1, id1)
 
-		id2, err := s.GenerateID()
+		id2, err := s.GenerateID(t.Context())
 		assert.NoError(t, err)
 		assert.Equal(t, 2, id2)
 	})
@@ -79,156 +82,239 @@ func TestStorageFS_List(t *testing.T) {
 	s.add(exp1, w)
 	s.add(exp2, w)
 
-	expenses, err := s.list(w)
+	expenses, err := s.list(t.Context(), w)
 	require.NoError(t, err)
 	assert.Equal(t, []Expense{exp1, exp2}, expenses)
 }
 
-type readerWriteTruncater struct {
-	s           string
-	i           int64 // current index
-	readErr     error
-	seekErr     error
-	truncateErr error
-	writeErr    error
-}
-
-func (rwt *readerWriteTruncater) Read(p []byte) (int, error) {
-	if rwt.readErr != nil {
-		return 0, rwt.readErr
+func TestStorageFS_Delete(t *testing.T) {
+	exp1 := Expense{
+		ID:          1,
+		Amount:      10,
+		Category:    "Food",
+		Date:        time.Date(2025, time.April, 25, 0, 0, 0, 0, time.UTC),
+		Description: "Lunch",
 	}
-
-	if rwt.i >= int64(len(rwt.s)) {
-		return 0, io.EOF
+	exp2 := Expense{
+		ID:          2,
+		Amount:      20,
+		Category:    "Food",
+		Date:        time.Date(2025, time.April, 25, 0, 0, 0, 0, time.UTC),
+		Description: "Lunch",
 	}
+	content := strings.Join(encode(exp1), ",") + "\n" + strings.Join(encode(exp2), ",") + "\n"
 
-	n := copy(p, rwt.s[rwt.i:])
-	rwt.i += int64(n)
+	t.Run("successful delete", func(t *testing.T) {
+		s := &StorageFS{}
+		records, deleted, err := s.delete([]int{1}, strings.NewReader(content))
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{encode(exp2)}, records)
+		assert.Equal(t, []Expense{exp1}, deleted)
+	})
 
-	return n, nil
-}
+	t.Run("read err", func(t *testing.T) {
+		readErr := errors.New("read error")
 
-func (rwt *readerWriteTruncater) Seek(offset int64, whence int) (int64, error) {
-	if rwt.seekErr != nil {
-		return 0, rwt.seekErr
-	}
+		s := &StorageFS{}
+		_, _, err := s.delete([]int{1}, iotest.ErrReader(readErr))
+		assert.ErrorIs(t, err, readErr)
+	})
 
-	var abs int64
-	switch whence {
-	case io.SeekStart:
-		abs = offset
-	case io.SeekCurrent:
-		abs = rwt.i + offset
-	case io.SeekEnd:
-		abs = int64(len(rwt.s)) + offset
-	default:
-		return 0, errors.New("invalid whence")
-	}
+	t.Run("not found", func(t *testing.T) {
+		s := &StorageFS{}
+		_, _, err := s.delete([]int{3}, strings.NewReader(content))
+		assert.ErrorIs(t, err, ErrExpenseNotFound)
+	})
 
-	if abs < 0 {
-		return 0, errors.New("negative position")
-	}
+	t.Run("rewrites the files", func(t *testing.T) {
+		dir := t.TempDir()
+		s := NewStorageFS(dir)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "expenses.txt"), []byte(formatHeaderLine()+content), 0644))
+
+		require.NoError(t, s.Delete(t.Context(), 1))
+		data, err := os.ReadFile(filepath.Join(dir, "expenses.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, formatHeaderLine()+strings.Join(encode(exp2), ",")+"\n", string(data))
+
+		trash, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, exp1, trash[0].Expense)
 
-	rwt.i = abs
-	return abs, nil
+		tmp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+		require.NoError(t, err)
+		assert.Empty(t, tmp, "the temporary files are renamed over the originals")
+	})
 }
 
-func (rwt *readerWriteTruncater) Write(p []byte) (int, error) {
-	if rwt.writeErr != nil {
-		return 0, rwt.writeErr
+func TestStorageFS_Replace(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)}))
+
+	replacement := []Expense{
+		{ID: 5, Amount: 20, Category: "Travel", Description: "Taxi", Date: time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC)},
 	}
+	require.NoError(t, s.Replace(t.Context(), replacement, 7))
 
-	rwt.s = rwt.s[:rwt.i] + string(p) + rwt.s[rwt.i:]
-	return len(p), nil
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, replacement, expenses)
+
+	id, err := s.GenerateID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 8, id)
 }
 
-func (rwt *readerWriteTruncater) Truncate(size int64) error {
-	if rwt.truncateErr != nil {
-		return rwt.truncateErr
-	}
+func TestStorageFS_ListWithExtension(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	exp1 := Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)}
+	exp2 := Expense{ID: 2, Amount: 20, Category: "Food", Description: "Dinner", Date: time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC), Notes: "With \"friends\",\nat home", Metadata: map[string]string{"project": "apollo"}}
+	require.NoError(t, s.Add(t.Context(), exp1))
+	require.NoError(t, s.Add(t.Context(), exp2))
 
-	if size < 0 {
-		return errors.New("negative size")
-	}
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{exp1, exp2}, expenses)
 
-	if size > int64(len(rwt.s)) {
-		return errors.New("size exceeds current length")
-	}
+	require.NoError(t, s.Delete(t.Context(), 1))
+	expenses, err = s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{exp2}, expenses)
+}
+
+func TestStorageFS_Update(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	exp1 := Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)}
+	exp2 := Expense{ID: 2, Amount: 20, Category: "Food", Description: "Dinner", Date: time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, s.Add(t.Context(), exp1))
+	require.NoError(t, s.Add(t.Context(), exp2))
 
-	rwt.s = rwt.s[:size]
+	exp2.Category = "Restaurants"
+	exp2.Tags = []string{"friends"}
+	require.NoError(t, s.Update(t.Context(), exp2))
 
-	return nil
+	missing := Expense{ID: 3, Amount: 5, Category: "Food", Description: "Snack", Date: exp1.Date}
+	exp1.Amount = 11
+	assert.ErrorIs(t, s.Update(t.Context(), exp1, missing), ErrExpenseNotFound)
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	exp1.Amount = 10
+	assert.Equal(t, []Expense{exp1, exp2}, expenses)
 }
 
-func TestStorageFS_Delete(t *testing.T) {
-	exp1 := Expense{
-		ID:          1,
-		Amount:      10,
-		Category:    "Food",
-		Date:        time.Date(2025, time.April, 25, 0, 0, 0, 0, time.UTC),
-		Description: "Lunch",
-	}
-	exp2 := Expense{
-		ID:          2,
-		Amount:      20,
-		Category:    "Food",
-		Date:        time.Date(2025, time.April, 25, 0, 0, 0, 0, time.UTC),
-		Description: "Lunch",
+func TestStorageFS_Trash(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	date := time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)
+	exp1 := Expense{ID: 1, Amount: 10, Category: "Food", Description: "Lunch", Date: date}
+	exp2 := Expense{ID: 2, Amount: 20, Category: "Food", Description: "Dinner", Date: date, Tags: []string{"friends"}}
+	exp3 := Expense{ID: 3, Amount: 30, Category: "Travel", Description: "Taxi", Date: date}
+	for _, expense := range []Expense{exp1, exp2, exp3} {
+		require.NoError(t, s.Add(t.Context(), expense))
 	}
-	t.Run("successful delete", func(t *testing.T) {
-		rwt := &readerWriteTruncater{
-			s: strings.Join([]string{
-				strings.Join(encode(exp1), ","),
-				strings.Join(encode(exp2), ",") + "\n",
-			}, "\n"),
-		}
 
-		expected := strings.Join(encode(exp2), ",") + "\n"
+	assert.ErrorIs(t, s.Delete(t.Context(), 4), ErrExpenseNotFound)
+	require.NoError(t, s.Delete(t.Context(), 2))
 
-		s := &StorageFS{}
-		err := s.delete(1, rwt)
-		assert.NoError(t, err)
-		assert.Equal(t, expected, rwt.s)
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []Expense{exp1, exp3}, expenses)
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, exp2, trash[0].Expense)
+	assert.WithinDuration(t, time.Now(), trash[0].DeletedAt, time.Minute)
+
+	t.Run("untrash keeps expenses ordered by ID", func(t *testing.T) {
+		assert.ErrorIs(t, s.Untrash(t.Context(), 1), ErrExpenseNotFound)
+		require.NoError(t, s.Untrash(t.Context(), 2))
+
+		expenses, err := s.List(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []Expense{exp1, exp2, exp3}, expenses)
+
+		trash, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, trash)
 	})
 
-	t.Run("truncate err", func(t *testing.T) {
-		str := strings.Join([]string{
-			strings.Join(encode(exp1), ","),
-			strings.Join(encode(exp2), ",") + "\n",
-		}, "\n")
-		rwt := &readerWriteTruncater{s: str}
-		rwt.truncateErr = errors.New("write error")
+	t.Run("empty trash only removes older expenses", func(t *testing.T) {
+		require.NoError(t, s.Delete(t.Context(), 1))
 
-		s := &StorageFS{}
-		err := s.delete(1, rwt)
-		assert.ErrorIs(t, err, rwt.truncateErr)
-		assert.Equal(t, str, rwt.s)
+		removed, err := s.EmptyTrash(t.Context(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 0, removed)
+
+		removed, err = s.EmptyTrash(t.Context(), time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		trash, err := s.Trash(t.Context())
+		require.NoError(t, err)
+		assert.Empty(t, trash)
 	})
+}
 
-	t.Run("seek err", func(t *testing.T) {
-		str := strings.Join([]string{
-			strings.Join(encode(exp1), ","),
-			strings.Join(encode(exp2), ",") + "\n",
-		}, "\n")
-		rwt := &readerWriteTruncater{s: str}
-		rwt.seekErr = errors.New("write error")
+func TestStorageFS_DeleteMany(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	date := time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)
+	for id := 1; id <= 4; id++ {
+		require.NoError(t, s.Add(t.Context(), Expense{ID: id, Amount: id, Category: "Food", Description: "Lunch", Date: date}))
+	}
 
-		s := &StorageFS{}
-		err := s.delete(1, rwt)
-		assert.ErrorIs(t, err, rwt.seekErr)
-		assert.Equal(t, str, rwt.s)
+	assert.ErrorIs(t, s.Delete(t.Context(), 1, 5), ErrExpenseNotFound)
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, expenses, 4)
+
+	require.NoError(t, s.Delete(t.Context(), 3, 1, 3))
+	expenses, err = s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, []int{expenses[0].ID, expenses[1].ID})
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, trash, 2)
+}
+
+func TestStorageFS_Scan(t *testing.T) {
+	s := NewStorageFS(t.TempDir())
+	for i, category := range []string{"Food", "Fun", "Food"} {
+		require.NoError(t, s.Add(t.Context(), Expense{ID: i + 1, Amount: 10, Category: category, Description: "Test", Date: time.Date(2025, time.April, 20, 0, 0, 0, 0, time.UTC)}))
+	}
+
+	var scanned []int
+	err := s.Scan(t.Context(), Filter{Category: "food"}, func(expense Expense) error {
+		scanned = append(scanned, expense.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, scanned)
+
+	t.Run("stops at error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := s.Scan(t.Context(), Filter{}, func(Expense) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
 	})
 
-	t.Run("not found", func(t *testing.T) {
-		str := strings.Join([]string{
-			strings.Join(encode(exp1), ","),
-			strings.Join(encode(exp2), ",") + "\n",
-		}, "\n")
-		rwt := &readerWriteTruncater{s: str}
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		err := s.Scan(ctx, Filter{}, func(Expense) error { return nil })
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 
-		s := &StorageFS{}
-		err := s.delete(3, rwt)
-		assert.ErrorIs(t, err, ErrExpenseNotFound)
-		assert.Equal(t, str, rwt.s)
+	t.Run("missing file", func(t *testing.T) {
+		err := NewStorageFS(t.TempDir()).Scan(t.Context(), Filter{}, func(Expense) error {
+			t.Fatal("no expense expected")
+			return nil
+		})
+		assert.NoError(t, err)
 	})
 }
@@ -0,0 +1,293 @@
+package expense
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// StorageMemory keeps the expenses in memory and is safe for concurrent use.
+// Faults and latency can be injected, so it doubles as a storage for tests which
+// need to see how the callers handle failing or slow storages.
+type StorageMemory struct {
+	mu       sync.Mutex
+	lastID   int
+	expenses []Expense
+	trash    []TrashedExpense
+
+	calls   int           // Number of calls made so far
+	failOn  int           // Call failing with failErr, 0 if none
+	failErr error         // Error returned by the failing call
+	latency time.Duration // Delay of every call
+}
+
+// NewStorageMemory creates an empty StorageMemory
+func NewStorageMemory() *StorageMemory {
+	return &StorageMemory{expenses: []Expense{}, trash: []TrashedExpense{}}
+}
+
+// FailOn makes the nth call from now on fail with err without changing anything, n starting from 1.
+// Calls to any of the storage methods are counted.
+func (s *StorageMemory) FailOn(n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failOn, s.failErr = s.calls+n, err
+}
+
+// SetLatency delays every call by d, a call returns early with the error of ctx once it is done
+func (s *StorageMemory) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Calls returns the number of calls made so far
+func (s *StorageMemory) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// call counts the call, waits the latency and returns the injected fault, if any.
+// On success the storage is returned locked and must be unlocked by the caller.
+func (s *StorageMemory) call(ctx context.Context) error {
+	s.mu.Lock()
+	s.calls++
+	latency := s.latency
+	var fault error
+	if s.calls == s.failOn {
+		fault = s.failErr
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if fault != nil {
+		return fault
+	}
+
+	s.mu.Lock()
+	return nil
+}
+
+// cloneExpense returns a copy of the expense sharing no slices or maps with it,
+// so the callers cannot change the stored expenses behind the storage's back
+func cloneExpense(e Expense) Expense {
+	e.Metadata = maps.Clone(e.Metadata)
+	e.Tags = slices.Clone(e.Tags)
+	e.Splits = slices.Clone(e.Splits)
+	e.Shares = slices.Clone(e.Shares)
+	return e
+}
+
+// cloneExpenses returns a deep copy of the expenses, never nil
+func cloneExpenses(expenses []Expense) []Expense {
+	cloned := make([]Expense, len(expenses))
+	for i, e := range expenses {
+		cloned[i] = cloneExpense(e)
+	}
+	return cloned
+}
+
+// cloneTrash returns a deep copy of the trashed expenses, never nil
+func cloneTrash(trash []TrashedExpense) []TrashedExpense {
+	cloned := make([]TrashedExpense, len(trash))
+	for i, t := range trash {
+		cloned[i] = TrashedExpense{Expense: cloneExpense(t.Expense), DeletedAt: t.DeletedAt}
+	}
+	return cloned
+}
+
+// index returns the index of the expense with the ID, -1 if there is none
+func (s *StorageMemory) index(id int) int {
+	return slices.IndexFunc(s.expenses, func(e Expense) bool { return e.ID == id })
+}
+
+// LastID returns the last generated ID, or 0 if no ID was generated yet.
+func (s *StorageMemory) LastID(ctx context.Context) (int, error) {
+	if err := s.call(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	return s.lastID, nil
+}
+
+// GenerateID generates a new unique ID for an expense starting from 1
+func (s *StorageMemory) GenerateID(ctx context.Context) (int, error) {
+	if err := s.call(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	s.lastID++
+	return s.lastID, nil
+}
+
+// Add appends the expense
+func (s *StorageMemory) Add(ctx context.Context, expense Expense) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	s.expenses = append(s.expenses, cloneExpense(expense))
+	return nil
+}
+
+// Delete moves the expenses to the trash.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is deleted.
+func (s *StorageMemory) Delete(ctx context.Context, ids ...int) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	for _, id := range ids {
+		if s.index(id) == -1 {
+			return ErrExpenseNotFound
+		}
+	}
+
+	now := time.Now()
+	for _, expense := range s.expenses {
+		if slices.Contains(ids, expense.ID) {
+			s.trash = append(s.trash, TrashedExpense{Expense: expense, DeletedAt: now})
+		}
+	}
+	s.expenses = slices.DeleteFunc(s.expenses, func(e Expense) bool { return slices.Contains(ids, e.ID) })
+	return nil
+}
+
+// Update replaces the expenses having the same IDs.
+// If any of the expenses does not exist, [ErrExpenseNotFound] is returned and nothing is updated.
+func (s *StorageMemory) Update(ctx context.Context, expenses ...Expense) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	for _, expense := range expenses {
+		if s.index(expense.ID) == -1 {
+			return ErrExpenseNotFound
+		}
+	}
+
+	for _, expense := range expenses {
+		s.expenses[s.index(expense.ID)] = cloneExpense(expense)
+	}
+	return nil
+}
+
+// List lists the expenses in the order they were added
+func (s *StorageMemory) List(ctx context.Context) ([]Expense, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	return cloneExpenses(s.expenses), nil
+}
+
+// Scan calls fn for each expense selected by the filter.
+// The expenses are copied first, so fn may call the storage.
+func (s *StorageMemory) Scan(ctx context.Context, filter Filter, fn func(Expense) error) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	expenses := cloneExpenses(s.expenses)
+	s.mu.Unlock()
+
+	return scanSlice(ctx, expenses, filter, fn)
+}
+
+// Trash lists the deleted expenses in the order they were deleted
+func (s *StorageMemory) Trash(ctx context.Context) ([]TrashedExpense, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	return cloneTrash(s.trash), nil
+}
+
+// Untrash moves the deleted expense with the ID from the trash back among the expenses.
+// If the expense is not in the trash, [ErrExpenseNotFound] is returned.
+func (s *StorageMemory) Untrash(ctx context.Context, id int) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	// The latest deletion wins should the trash hold the ID more than once
+	var restored *Expense
+	for i := len(s.trash) - 1; i >= 0 && restored == nil; i-- {
+		if s.trash[i].ID == id {
+			restored = &s.trash[i].Expense
+		}
+	}
+	if restored == nil {
+		return ErrExpenseNotFound
+	}
+	if s.index(id) != -1 {
+		return ErrExpenseExists
+	}
+
+	// Keep the expenses ordered by ID
+	at := slices.IndexFunc(s.expenses, func(e Expense) bool { return e.ID > id })
+	if at == -1 {
+		at = len(s.expenses)
+	}
+	s.expenses = slices.Insert(s.expenses, at, *restored)
+	s.trash = slices.DeleteFunc(s.trash, func(t TrashedExpense) bool { return t.ID == id })
+	return nil
+}
+
+// EmptyTrash permanently removes the expenses deleted before the time and returns how many were removed
+func (s *StorageMemory) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	if err := s.call(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	n := len(s.trash)
+	s.trash = slices.DeleteFunc(s.trash, func(t TrashedExpense) bool { return t.DeletedAt.Before(before) })
+	return n - len(s.trash), nil
+}
+
+// ReplaceTrash replaces the deleted expenses in the trash
+func (s *StorageMemory) ReplaceTrash(ctx context.Context, trash []TrashedExpense) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	s.trash = cloneTrash(trash)
+	return nil
+}
+
+// Replace replaces all expenses and resets the ID counter to lastID.
+func (s *StorageMemory) Replace(ctx context.Context, expenses []Expense, lastID int) error {
+	if err := s.call(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	s.expenses = cloneExpenses(expenses)
+	s.lastID = lastID
+	return nil
+}
+
+var _ ExpenseStorage = (*StorageMemory)(nil)
@@ -0,0 +1,68 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageMemory_FailOn(t *testing.T) {
+	s := NewStorageMemory()
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 1, Amount: 10}))
+
+	fault := errors.New("disk full")
+	s.FailOn(2, fault)
+
+	_, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Add(t.Context(), Expense{ID: 2, Amount: 20}), fault)
+	require.NoError(t, s.Add(t.Context(), Expense{ID: 3, Amount: 30}), "only the nth call fails")
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, ids(expenses), "the failing call changes nothing")
+	assert.Equal(t, 5, s.Calls())
+}
+
+func TestStorageMemory_SetLatency(t *testing.T) {
+	s := NewStorageMemory()
+	s.SetLatency(time.Hour)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.GenerateID(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	s.SetLatency(0)
+	lastID, err := s.LastID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 0, lastID, "the cancelled call changes nothing")
+}
+
+func TestStorageMemory_Concurrent(t *testing.T) {
+	s := NewStorageMemory()
+	s.SetLatency(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := s.GenerateID(t.Context())
+			if assert.NoError(t, err) {
+				assert.NoError(t, s.Add(t.Context(), Expense{ID: id, Amount: id}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, expenses, 20)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, ids(expenses))
+}
@@ -0,0 +1,310 @@
+// Package storagetest provides a conformance test suite for implementations of [expense.ExpenseStorage].
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+// Run runs the conformance tests against the storages created by newStorage,
+// which is called for each test and must return an empty storage
+func Run(t *testing.T, newStorage func(t *testing.T) expense.ExpenseStorage) {
+	t.Run("GenerateID", func(t *testing.T) { testGenerateID(t, newStorage(t)) })
+	t.Run("AddList", func(t *testing.T) { testAddList(t, newStorage(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStorage(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStorage(t)) })
+	t.Run("Scan", func(t *testing.T) { testScan(t, newStorage(t)) })
+	t.Run("Untrash", func(t *testing.T) { testUntrash(t, newStorage(t)) })
+	t.Run("EmptyTrash", func(t *testing.T) { testEmptyTrash(t, newStorage(t)) })
+	t.Run("ReplaceTrash", func(t *testing.T) { testReplaceTrash(t, newStorage(t)) })
+	t.Run("Replace", func(t *testing.T) { testReplace(t, newStorage(t)) })
+	t.Run("Isolation", func(t *testing.T) { testIsolation(t, newStorage(t)) })
+	t.Run("Cancelled", func(t *testing.T) { testCancelled(t, newStorage(t)) })
+}
+
+// at returns a date in April 2025
+func at(day int) time.Time {
+	return time.Date(2025, time.April, day, 0, 0, 0, 0, time.UTC)
+}
+
+// add generates an ID for each of the expenses and adds them, returning them with their IDs
+func add(t *testing.T, s expense.ExpenseStorage, expenses ...expense.Expense) []expense.Expense {
+	t.Helper()
+	for i := range expenses {
+		id, err := s.GenerateID(t.Context())
+		require.NoError(t, err)
+		expenses[i].ID = id
+		require.NoError(t, s.Add(t.Context(), expenses[i]))
+	}
+	return expenses
+}
+
+// list lists the expenses, failing the test on error
+func list(t *testing.T, s expense.ExpenseStorage) []expense.Expense {
+	t.Helper()
+	expenses, err := s.List(t.Context())
+	require.NoError(t, err)
+	return expenses
+}
+
+// ids returns the IDs of the expenses
+func ids(expenses []expense.Expense) []int {
+	ids := make([]int, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func testGenerateID(t *testing.T, s expense.ExpenseStorage) {
+	lastID, err := s.LastID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 0, lastID)
+
+	for want := 1; want <= 3; want++ {
+		id, err := s.GenerateID(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, want, id)
+	}
+
+	lastID, err = s.LastID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 3, lastID)
+}
+
+func testAddList(t *testing.T, s expense.ExpenseStorage) {
+	expenses := list(t, s)
+	assert.NotNil(t, expenses)
+	assert.Empty(t, expenses)
+
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Travel", Description: "Taxi", Amount: 30, Tags: []string{"work"}},
+		expense.Expense{Date: at(22), Category: "Food", Description: "Dinner", Amount: 25, Notes: "With friends"},
+	)
+	assert.Equal(t, added, list(t, s), "expenses are listed in the order they were added")
+}
+
+func testUpdate(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Food", Description: "Dinner", Amount: 20},
+	)
+
+	updated := added[1]
+	updated.Amount, updated.Category = 25, "Fun"
+	require.NoError(t, s.Update(t.Context(), updated))
+	assert.Equal(t, []expense.Expense{added[0], updated}, list(t, s))
+
+	t.Run("missing expense updates nothing", func(t *testing.T) {
+		changed := added[0]
+		changed.Amount = 99
+		err := s.Update(t.Context(), changed, expense.Expense{ID: 42, Date: at(1), Category: "Food", Description: "Ghost", Amount: 1})
+		assert.ErrorIs(t, err, expense.ErrExpenseNotFound)
+		assert.Equal(t, []expense.Expense{added[0], updated}, list(t, s))
+	})
+}
+
+func testDelete(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Food", Description: "Dinner", Amount: 20},
+		expense.Expense{Date: at(22), Category: "Food", Description: "Snack", Amount: 5},
+	)
+
+	before := time.Now().Add(-time.Second)
+	require.NoError(t, s.Delete(t.Context(), added[0].ID, added[2].ID, added[2].ID))
+	assert.Equal(t, []expense.Expense{added[1]}, list(t, s))
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	for i, want := range []expense.Expense{added[0], added[2]} {
+		assert.Equal(t, want, trash[i].Expense)
+		assert.False(t, trash[i].DeletedAt.Before(before), "deletion time is recorded")
+	}
+
+	t.Run("missing expense deletes nothing", func(t *testing.T) {
+		assert.ErrorIs(t, s.Delete(t.Context(), added[1].ID, added[0].ID), expense.ErrExpenseNotFound)
+		assert.Equal(t, []expense.Expense{added[1]}, list(t, s))
+	})
+}
+
+func testScan(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Travel", Description: "Taxi", Amount: 30},
+		expense.Expense{Date: at(22), Category: "Food", Description: "Dinner", Amount: 25},
+	)
+
+	var scanned []expense.Expense
+	err := s.Scan(t.Context(), expense.Filter{Category: "Food"}, func(e expense.Expense) error {
+		scanned = append(scanned, e)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []expense.Expense{added[0], added[2]}, scanned)
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := s.Scan(t.Context(), expense.Filter{}, func(expense.Expense) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func testUntrash(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Food", Description: "Dinner", Amount: 20},
+		expense.Expense{Date: at(22), Category: "Food", Description: "Snack", Amount: 5},
+	)
+	require.NoError(t, s.Delete(t.Context(), added[0].ID, added[1].ID))
+
+	require.NoError(t, s.Untrash(t.Context(), added[1].ID))
+	require.NoError(t, s.Untrash(t.Context(), added[0].ID))
+	assert.Equal(t, added, list(t, s), "restored expenses are kept ordered by ID")
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	assert.ErrorIs(t, s.Untrash(t.Context(), added[0].ID), expense.ErrExpenseNotFound)
+
+	t.Run("expense already exists", func(t *testing.T) {
+		require.NoError(t, s.Delete(t.Context(), added[2].ID))
+		require.NoError(t, s.Add(t.Context(), added[2]))
+		assert.ErrorIs(t, s.Untrash(t.Context(), added[2].ID), expense.ErrExpenseExists)
+	})
+}
+
+func testEmptyTrash(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s,
+		expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10},
+		expense.Expense{Date: at(21), Category: "Food", Description: "Dinner", Amount: 20},
+	)
+	require.NoError(t, s.Delete(t.Context(), added[0].ID, added[1].ID))
+
+	removed, err := s.EmptyTrash(t.Context(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed, "recently deleted expenses are kept")
+
+	removed, err = s.EmptyTrash(t.Context(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	assert.ErrorIs(t, s.Untrash(t.Context(), added[0].ID), expense.ErrExpenseNotFound)
+}
+
+func testReplaceTrash(t *testing.T, s expense.ExpenseStorage) {
+	added := add(t, s, expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10})
+	require.NoError(t, s.Delete(t.Context(), added[0].ID))
+
+	deletedAt := time.Date(2025, time.May, 1, 12, 0, 0, 0, time.UTC)
+	replacing := []expense.TrashedExpense{
+		{Expense: expense.Expense{ID: 5, Date: at(2), Category: "Rent", Description: "April", Amount: 900}, DeletedAt: deletedAt},
+	}
+	require.NoError(t, s.ReplaceTrash(t.Context(), replacing))
+
+	trash, err := s.Trash(t.Context())
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, replacing[0].Expense, trash[0].Expense)
+	assert.True(t, deletedAt.Equal(trash[0].DeletedAt))
+
+	require.NoError(t, s.Untrash(t.Context(), 5))
+	assert.Equal(t, []int{5}, ids(list(t, s)))
+}
+
+func testReplace(t *testing.T, s expense.ExpenseStorage) {
+	add(t, s, expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10})
+
+	replacing := []expense.Expense{
+		{ID: 7, Date: at(1), Category: "Rent", Description: "April", Amount: 900},
+		{ID: 9, Date: at(2), Category: "Food", Description: "Groceries", Amount: 60},
+	}
+	require.NoError(t, s.Replace(t.Context(), slices.Clone(replacing), 12))
+	assert.Equal(t, replacing, list(t, s))
+
+	lastID, err := s.LastID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 12, lastID)
+
+	id, err := s.GenerateID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 13, id)
+
+	t.Run("with no expenses", func(t *testing.T) {
+		require.NoError(t, s.Replace(t.Context(), nil, 0))
+		expenses := list(t, s)
+		assert.NotNil(t, expenses)
+		assert.Empty(t, expenses)
+	})
+}
+
+func testIsolation(t *testing.T, s expense.ExpenseStorage) {
+	newExpense := func() expense.Expense {
+		return expense.Expense{
+			Date: at(20), Category: "Food", Description: "Lunch", Amount: 10,
+			Tags: []string{"work"}, Metadata: map[string]string{"project": "apollo"},
+		}
+	}
+	added := add(t, s, newExpense())
+	want := newExpense()
+	want.ID = added[0].ID
+
+	// Changing the added, listed or scanned expenses must not change the stored ones
+	added[0].Tags[0], added[0].Metadata["project"] = "added", "added"
+	listed := list(t, s)
+	listed[0].Tags[0], listed[0].Metadata["project"] = "listed", "listed"
+	require.NoError(t, s.Scan(t.Context(), expense.Filter{}, func(e expense.Expense) error {
+		e.Tags[0], e.Metadata["project"] = "scanned", "scanned"
+		return nil
+	}))
+	assert.Equal(t, []expense.Expense{want}, list(t, s))
+
+	t.Run("replaced expenses", func(t *testing.T) {
+		replacing := []expense.Expense{want}
+		replacing[0].Tags, replacing[0].Metadata = []string{"home"}, map[string]string{"project": "gemini"}
+		require.NoError(t, s.Replace(t.Context(), replacing, want.ID))
+		replacing[0].Tags[0], replacing[0].Metadata["project"] = "replaced", "replaced"
+
+		listed := list(t, s)
+		assert.Equal(t, []string{"home"}, listed[0].Tags)
+		assert.Equal(t, map[string]string{"project": "gemini"}, listed[0].Metadata)
+	})
+}
+
+func testCancelled(t *testing.T, s expense.ExpenseStorage) {
+	add(t, s, expense.Expense{Date: at(20), Category: "Food", Description: "Lunch", Amount: 10})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := s.List(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = s.Scan(ctx, expense.Filter{}, func(expense.Expense) error {
+		t.Error("no expense expected once cancelled")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.ErrorIs(t, s.Add(ctx, expense.Expense{ID: 2, Date: at(21), Category: "Food", Description: "Dinner", Amount: 20}), context.Canceled)
+	assert.Len(t, list(t, s), 1, "nothing is added once cancelled")
+}
@@ -0,0 +1,19 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/toramanomer/expense-tracker/expense"
+)
+
+func TestStorageFS(t *testing.T) {
+	Run(t, func(t *testing.T) expense.ExpenseStorage { return expense.NewStorageFS(t.TempDir()) })
+}
+
+func TestStorageEvents(t *testing.T) {
+	Run(t, func(t *testing.T) expense.ExpenseStorage { return expense.NewStorageEvents(t.TempDir()) })
+}
+
+func TestStorageMemory(t *testing.T) {
+	Run(t, func(t *testing.T) expense.ExpenseStorage { return expense.NewStorageMemory() })
+}
@@ -0,0 +1,109 @@
+package expense
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Suggestion is a category suggested for an expense
+type Suggestion struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"` // Probability of the category between 0 and 1
+}
+
+// tokenize splits the text into lowercase words of at least two letters or digits
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) >= 2 {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// categoryModel holds the token counts of a category
+type categoryModel struct {
+	name      string         // Most recently used spelling of the category
+	documents int            // Number of expenses in the category
+	tokens    map[string]int // Number of occurrences of each token
+	total     int            // Number of token occurrences
+}
+
+// SuggestCategories suggests up to n categories for an expense with the description and payee,
+// ranked by a multinomial naive Bayes classifier trained on the history of expenses.
+// Categories are compared case-insensitively. No suggestion is made if none of the words
+// of the description and payee occur in the history.
+func SuggestCategories(history []Expense, description, payee string, n int) []Suggestion {
+	var (
+		models     = make(map[string]*categoryModel)
+		vocabulary = make(map[string]struct{})
+	)
+	for _, expense := range history {
+		key := strings.ToLower(expense.Category)
+		model, ok := models[key]
+		if !ok {
+			model = &categoryModel{tokens: make(map[string]int)}
+			models[key] = model
+		}
+
+		model.name = expense.Category
+		model.documents++
+		for _, token := range tokenize(expense.Description + " " + expense.Payee) {
+			model.tokens[token]++
+			model.total++
+			vocabulary[token] = struct{}{}
+		}
+	}
+
+	var tokens []string
+	for _, token := range tokenize(description + " " + payee) {
+		if _, ok := vocabulary[token]; ok {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// Log probabilities with Laplace smoothing, turned into confidences with a softmax
+	var (
+		suggestions = make([]Suggestion, 0, len(models))
+		scores      = make([]float64, 0, len(models))
+		best        = math.Inf(-1)
+	)
+	for _, model := range models {
+		score := math.Log(float64(model.documents) / float64(len(history)))
+		for _, token := range tokens {
+			score += math.Log(float64(model.tokens[token]+1) / float64(model.total+len(vocabulary)))
+		}
+
+		suggestions = append(suggestions, Suggestion{Category: model.name})
+		scores = append(scores, score)
+		best = max(best, score)
+	}
+
+	var sum float64
+	for i, score := range scores {
+		scores[i] = math.Exp(score - best)
+		sum += scores[i]
+	}
+	for i := range suggestions {
+		suggestions[i].Confidence = scores[i] / sum
+	}
+
+	slices.SortFunc(suggestions, func(a, b Suggestion) int {
+		return cmp.Or(cmp.Compare(b.Confidence, a.Confidence), cmp.Compare(a.Category, b.Category))
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
@@ -0,0 +1,54 @@
+package expense
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"oat", "latte", "x2"}, tokenize("Oat-LATTE, a x2!"))
+}
+
+func TestSuggestCategories(t *testing.T) {
+	history := []Expense{
+		{Category: "Coffee", Description: "Oat latte", Payee: "Starbucks"},
+		{Category: "coffee", Description: "Espresso"},
+		{Category: "Coffee", Description: "Latte"},
+		{Category: "Food", Description: "Lunch sandwich"},
+		{Category: "Food", Description: "Dinner"},
+		{Category: "Travel", Description: "Taxi to airport"},
+	}
+
+	t.Run("no known words", func(t *testing.T) {
+		assert.Empty(t, SuggestCategories(history, "Concert tickets", "", 3))
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		assert.Empty(t, SuggestCategories(nil, "Latte", "", 3))
+	})
+
+	t.Run("ranks by similarity", func(t *testing.T) {
+		suggestions := SuggestCategories(history, "Large latte", "", 3)
+		require.Len(t, suggestions, 3)
+		assert.Equal(t, "Coffee", suggestions[0].Category)
+		assert.Greater(t, suggestions[0].Confidence, suggestions[1].Confidence)
+
+		var sum float64
+		for _, suggestion := range suggestions {
+			sum += suggestion.Confidence
+		}
+		assert.InDelta(t, 1, sum, 1e-9)
+	})
+
+	t.Run("uses payee", func(t *testing.T) {
+		suggestions := SuggestCategories(history, "Something new", "Starbucks", 1)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, "Coffee", suggestions[0].Category)
+	})
+
+	t.Run("limits suggestions", func(t *testing.T) {
+		assert.Len(t, SuggestCategories(history, "Taxi", "", 2), 2)
+	})
+}
@@ -0,0 +1,116 @@
+package expense
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// GroupBy is the field expenses are grouped by in a summary
+type GroupBy string
+
+const (
+	GroupByCategory GroupBy = "category"
+	GroupByAccount  GroupBy = "account"
+	GroupByPayee    GroupBy = "payee"
+)
+
+// GroupBys lists all fields expenses can be grouped by
+var GroupBys = []GroupBy{GroupByCategory, GroupByAccount, GroupByPayee}
+
+// ParseGroupBy parses the field to group expenses by and returns an error if it's invalid
+func ParseGroupBy(by string) (GroupBy, error) {
+	g := GroupBy(strings.ToLower(strings.TrimSpace(by)))
+	if !slices.Contains(GroupBys, g) {
+		names := make([]string, len(GroupBys))
+		for i, g := range GroupBys {
+			names[i] = string(g)
+		}
+		return "", fmt.Errorf("invalid group %q: must be one of %s", by, strings.Join(names, ", "))
+	}
+	return g, nil
+}
+
+// key returns the group of the expense, empty if the field is not set
+func (g GroupBy) key(expense Expense) string {
+	switch g {
+	case GroupByAccount:
+		return expense.Account
+	case GroupByPayee:
+		return expense.Payee
+	default:
+		return expense.Category
+	}
+}
+
+// GroupTotal is the total amount of the expenses in a group
+type GroupTotal struct {
+	Group string `json:"group"` // Value of the grouped field, empty for expenses without it
+	Total int    `json:"total"` // Total amount of the expenses
+	Count int    `json:"count"` // Number of expenses, a split expense counting once in each of its categories
+}
+
+// GroupAccumulator sums expenses by a field one at a time, holding only the totals of the groups.
+// Groups are compared case-insensitively. When grouping by category, the amounts of split expenses are
+// attributed to the categories of their splits. Refunds reduce the total of their group, income and transfers are left out.
+type GroupAccumulator struct {
+	by     GroupBy
+	totals []GroupTotal
+	index  map[string]int
+}
+
+// NewGroupAccumulator creates a new GroupAccumulator summing expenses by the field
+func NewGroupAccumulator(by GroupBy) *GroupAccumulator {
+	return &GroupAccumulator{by: by, index: make(map[string]int)}
+}
+
+// add adds the amount and count to the total of the group, compared case-insensitively
+func (g *GroupAccumulator) add(group string, amount, count int) {
+	key := strings.ToLower(group)
+
+	i, ok := g.index[key]
+	if !ok {
+		i = len(g.totals)
+		g.index[key] = i
+		g.totals = append(g.totals, GroupTotal{Group: group})
+	}
+
+	g.totals[i].Total += amount
+	g.totals[i].Count += count
+}
+
+// Add adds the expense to the total of its group
+func (g *GroupAccumulator) Add(expense Expense) {
+	if !expense.IsExpense() {
+		return
+	}
+
+	// Refunds reduce the total of their group without counting as an expense
+	if expense.IsRefund() {
+		g.add(g.by.key(expense), -expense.Amount, 0)
+		return
+	}
+
+	// Split expenses count towards the category of each split, once however many splits share it
+	if g.by == GroupByCategory && len(expense.Splits) > 0 {
+		counted := make(map[string]bool, len(expense.Splits))
+		for _, split := range expense.Splits {
+			key := strings.ToLower(split.Category)
+			g.add(split.Category, split.Amount, boolInt(!counted[key]))
+			counted[key] = true
+		}
+		return
+	}
+
+	g.add(g.by.key(expense), expense.Amount, 1)
+}
+
+// Totals returns the totals of the groups sorted by their total descending, then by name
+func (g *GroupAccumulator) Totals() []GroupTotal {
+	totals := slices.Clone(g.totals)
+	slices.SortStableFunc(totals, func(a, b GroupTotal) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Group, b.Group))
+	})
+	return totals
+}
@@ -0,0 +1,114 @@
+package expense
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	t.Run("fails with unknown field", func(t *testing.T) {
+		_, err := ParseGroupBy("description")
+		assert.Error(t, err)
+	})
+	t.Run("parses case-insensitively", func(t *testing.T) {
+		g, err := ParseGroupBy(" Account ")
+		require.NoError(t, err)
+		assert.Equal(t, GroupByAccount, g)
+	})
+}
+
+// groupTotals sums the expenses with a GroupAccumulator
+func groupTotals(expenses []Expense, by GroupBy) []GroupTotal {
+	totals := NewGroupAccumulator(by)
+	for _, expense := range expenses {
+		totals.Add(expense)
+	}
+	return totals.Totals()
+}
+
+func TestGroupTotals(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 10, Category: "Food", Account: "Amex"},
+		{ID: 2, Amount: 30, Category: "Travel", Account: "amex"},
+		{ID: 3, Amount: 15, Category: "food", Account: "Cash"},
+		{ID: 4, Amount: 5, Category: "Coffee"},
+	}
+
+	t.Run("by category", func(t *testing.T) {
+		assert.Equal(t, []GroupTotal{
+			{Group: "Travel", Total: 30, Count: 1},
+			{Group: "Food", Total: 25, Count: 2},
+			{Group: "Coffee", Total: 5, Count: 1},
+		}, groupTotals(expenses, GroupByCategory))
+	})
+
+	t.Run("by account", func(t *testing.T) {
+		assert.Equal(t, []GroupTotal{
+			{Group: "Amex", Total: 40, Count: 2},
+			{Group: "Cash", Total: 15, Count: 1},
+			{Group: "", Total: 5, Count: 1},
+		}, groupTotals(expenses, GroupByAccount))
+	})
+}
+
+func TestGroupTotals_Splits(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 55, Category: "Groceries", Account: "Amex", Splits: []Split{
+			{Category: "Groceries", Amount: 40},
+			{Category: "Household", Amount: 15},
+		}},
+		{ID: 2, Amount: 10, Category: "household", Account: "Amex"},
+		{ID: 3, Amount: 30, Category: "Groceries", Account: "Amex", Splits: []Split{
+			{Category: "Groceries", Amount: 20, Note: "Food"},
+			{Category: "groceries", Amount: 10, Note: "Drinks"},
+		}},
+	}
+
+	assert.Equal(t, []GroupTotal{
+		{Group: "Groceries", Total: 70, Count: 2},
+		{Group: "Household", Total: 25, Count: 2},
+	}, groupTotals(expenses, GroupByCategory), "an expense counts once however many of its splits share a category")
+
+	assert.Equal(t, []GroupTotal{
+		{Group: "Amex", Total: 95, Count: 3},
+	}, groupTotals(expenses, GroupByAccount))
+}
+
+func TestGroupTotals_IgnoresIncomeAndTransfers(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 10, Category: "Food"},
+		{ID: 2, Amount: 3000, Category: "Salary", Kind: KindIncome},
+		{ID: 3, Amount: 500, Category: "Savings", Kind: KindTransfer},
+	}
+
+	assert.Equal(t, []GroupTotal{{Group: "Food", Total: 10, Count: 1}}, groupTotals(expenses, GroupByCategory))
+}
+
+func TestGroupTotals_Refunds(t *testing.T) {
+	expenses := []Expense{
+		{ID: 1, Amount: 100, Category: "Clothes"},
+		{ID: 2, Amount: 20, Category: "Food"},
+		{ID: 3, Amount: 40, Category: "Clothes", RefundOf: 1},
+	}
+
+	assert.Equal(t, []GroupTotal{
+		{Group: "Clothes", Total: 60, Count: 1},
+		{Group: "Food", Total: 20, Count: 1},
+	}, groupTotals(expenses, GroupByCategory))
+}
+
+func TestGroupAccumulator(t *testing.T) {
+	totals := NewGroupAccumulator(GroupByCategory)
+	totals.Add(Expense{ID: 1, Amount: 10, Category: "Food"})
+	assert.Equal(t, []GroupTotal{{Group: "Food", Total: 10, Count: 1}}, totals.Totals())
+
+	// Totals can be taken while adding, later expenses update them
+	totals.Add(Expense{ID: 2, Amount: 30, Category: "Travel"})
+	totals.Add(Expense{ID: 3, Amount: 5, Category: "food"})
+	assert.Equal(t, []GroupTotal{
+		{Group: "Travel", Total: 30, Count: 1},
+		{Group: "Food", Total: 15, Count: 2},
+	}, totals.Totals())
+}
@@ -0,0 +1,161 @@
+package expense
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// TrashedExpense is a deleted expense kept in the trash until it is restored or the trash is emptied
+type TrashedExpense struct {
+	Expense
+	DeletedAt time.Time `json:"deleted_at"` // Time the expense was deleted
+}
+
+// encodeTrashed encodes a trashed expense as its deletion time followed by the expense columns
+func encodeTrashed(trashed TrashedExpense) []string {
+	return append([]string{trashed.DeletedAt.Format(time.RFC3339)}, encode(trashed.Expense)...)
+}
+
+// decodeTrashed decodes a record written by encodeTrashed
+func decodeTrashed(record []string) (*TrashedExpense, error) {
+	if len(record) == 0 {
+		return nil, errors.New("unexpected record length")
+	}
+
+	deletedAt, err := time.Parse(time.RFC3339, record[0])
+	if err != nil {
+		return nil, errors.New("invalid deletion time: not in RFC 3339 format")
+	}
+
+	expense, err := decode(record[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrashedExpense{Expense: *expense, DeletedAt: deletedAt}, nil
+}
+
+// Trash lists the deleted expenses in the order they were deleted
+func (s *StorageFS) Trash(ctx context.Context) ([]TrashedExpense, error) {
+	file, err := os.Open(s.trashfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashedExpense{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := newReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	trash := make([]TrashedExpense, len(records))
+	for i, record := range records {
+		trashed, err := decodeTrashed(record)
+		if err != nil {
+			return nil, err
+		}
+		trash[i] = *trashed
+	}
+
+	return trash, nil
+}
+
+// writeTrash writes the trashed expenses as CSV
+func (s *StorageFS) writeTrash(w io.Writer, trash []TrashedExpense) error {
+	writer := csv.NewWriter(w)
+	for _, trashed := range trash {
+		if err := writer.Write(encodeTrashed(trashed)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// replaceTrash replaces the content of the trash, see [writeFileAtomic]
+func (s *StorageFS) replaceTrash(trash []TrashedExpense) error {
+	var buf bytes.Buffer
+	if err := s.writeTrash(&buf, trash); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.trashfile, buf.Bytes())
+}
+
+// ReplaceTrash replaces the deleted expenses in the trash
+func (s *StorageFS) ReplaceTrash(ctx context.Context, trash []TrashedExpense) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.replaceTrash(trash)
+}
+
+// Untrash moves the deleted expense with the ID from the trash back among the expenses.
+// If the expense is not in the trash, [ErrExpenseNotFound] is returned.
+func (s *StorageFS) Untrash(ctx context.Context, id int) error {
+	trash, err := s.Trash(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The latest deletion wins should the trash hold the ID more than once
+	var restored *Expense
+	for i := len(trash) - 1; i >= 0 && restored == nil; i-- {
+		if trash[i].ID == id {
+			restored = &trash[i].Expense
+		}
+	}
+	if restored == nil {
+		return ErrExpenseNotFound
+	}
+
+	expenses, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(expenses, func(e Expense) bool { return e.ID == id }) {
+		return ErrExpenseExists
+	}
+
+	// Keep the expenses ordered by ID
+	at := slices.IndexFunc(expenses, func(e Expense) bool { return e.ID > id })
+	if at == -1 {
+		at = len(expenses)
+	}
+
+	// The expense is taken out of the trash first, so a failure cannot leave it both restored and trashed
+	if err := s.replaceTrash(slices.DeleteFunc(slices.Clone(trash), func(t TrashedExpense) bool { return t.ID == id })); err != nil {
+		return err
+	}
+	if err := s.writeAll(slices.Insert(expenses, at, *restored)); err != nil {
+		// Put it back in the trash, so it is not lost
+		if rollbackErr := s.replaceTrash(trash); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// EmptyTrash permanently removes the expenses deleted before the time and returns how many were removed
+func (s *StorageFS) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	trash, err := s.Trash(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(trash), func(t TrashedExpense) bool { return t.DeletedAt.Before(before) })
+	if len(kept) == len(trash) {
+		return 0, nil
+	}
+
+	return len(trash) - len(kept), s.replaceTrash(kept)
+}
@@ -2,22 +2,31 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/charmbracelet/fang"
 
 	"github.com/toramanomer/expense-tracker/cmd"
-	"github.com/toramanomer/expense-tracker/expense"
+	"github.com/toramanomer/expense-tracker/config"
 )
 
 func main() {
-	var (
-		storage  = expense.NewStorageFS("data")
-		service  = expense.NewExpenseService(storage)
-		commands = cmd.NewCommands(service)
-	)
+	configPath, err := config.Path()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locating configuration:", err)
+		os.Exit(1)
+	}
+
+	commands := cmd.NewCommands(configPath)
+
+	// Ctrl-C cancels the context, stopping the command at the next storage operation
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	if err := fang.Execute(context.Background(), commands.RootCommand()); err != nil {
+	if err := fang.Execute(ctx, commands.RootCommand()); err != nil {
+		stop()
 		os.Exit(1)
 	}
 }